@@ -0,0 +1,79 @@
+package rester
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/hoenirvili/rester/audit"
+	"github.com/hoenirvili/rester/request"
+)
+
+// WithAuditSink records every authorization decision taken
+// on the secured routes, including the rejected tokens
+func WithAuditSink(s audit.Sink) Option {
+	return func(opts *Options) { opts.audit = s }
+}
+
+// target is the secured route being authorized
+type target struct {
+	pattern string
+	access  func() access
+}
+
+type targetKey struct{}
+
+// withTarget stores the route in the request context so the
+// token rejections can be recorded along with the route
+func withTarget(t *target) middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			ctx := context.WithValue(req.Context(), targetKey{}, t)
+			next.ServeHTTP(w, req.WithContext(ctx))
+		})
+	}
+}
+
+// record sends the authorization decision taken on the request to the audit sink
+func (r *Rester) record(req *http.Request, t *target, d audit.Decision, reason string) {
+	if r.options.audit == nil {
+		return
+	}
+	e := audit.Event{
+		Time:     time.Now().UTC(),
+		Route:    req.URL.Path,
+		Method:   req.Method,
+		Decision: d,
+		Reason:   reason,
+		ClientIP: clientIP(req),
+	}
+	if t == nil {
+		t, _ = req.Context().Value(targetKey{}).(*target)
+	}
+	if t != nil {
+		a := t.access()
+		e.Route = t.pattern
+		e.Required = a.allow
+		if !a.roles.Empty() {
+			e.RequiredRoles = a.roles.Names()
+		}
+	}
+	if c := request.New(req, nil).Claims(); c != nil {
+		e.Subject = c.Subject
+		e.Held = c.Permissions
+		if !c.Roles.Empty() {
+			e.HeldRoles = c.Roles.Names()
+		}
+	}
+	r.options.audit.Record(e)
+}
+
+// clientIP returns the host of the request remote address
+func clientIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
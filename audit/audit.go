@@ -0,0 +1,80 @@
+// Package audit defines the events recorded for every
+// authorization decision taken by rester and the sinks storing them
+package audit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hoenirvili/rester/permission"
+)
+
+// Decision is the outcome of an authorization check
+type Decision string
+
+const (
+	// Allow means that the request reached the route handler
+	Allow Decision = "allow"
+	// Deny means that the request was rejected
+	Deny Decision = "deny"
+)
+
+// Event describes a single authorization decision
+type Event struct {
+	// Time is when the decision was taken
+	Time time.Time `json:"time"`
+	// Route is the url pattern of the route, e.g. /users/{id}
+	Route string `json:"route"`
+	// Method is the http method of the request
+	Method string `json:"method"`
+	// Subject is the "sub" claim of the token, if any
+	Subject string `json:"subject,omitempty"`
+	// Required holds the permissions required by the route
+	Required permission.Permissions `json:"required"`
+	// Held holds the permissions of the token
+	Held permission.Permissions `json:"held"`
+	// RequiredRoles holds the names of the roles required by the route
+	RequiredRoles []string `json:"required_roles,omitempty"`
+	// HeldRoles holds the names of the roles of the token
+	HeldRoles []string `json:"held_roles,omitempty"`
+	// Decision is the outcome of the authorization
+	Decision Decision `json:"decision"`
+	// Reason explains why the request was denied
+	Reason string `json:"reason,omitempty"`
+	// ClientIP is the address of the client that made the request
+	ClientIP string `json:"client_ip"`
+}
+
+// Sink receives every authorization decision
+// Record is called concurrently and it should not block the request for long
+type Sink interface {
+	Record(e Event)
+}
+
+// Memory is a Sink that keeps all the events in memory
+// The zero value is ready to use
+type Memory struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// Record appends the event
+func (m *Memory) Record(e Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, e)
+}
+
+// Events returns all the recorded events in the order they were recorded
+func (m *Memory) Events() []Event {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Event(nil), m.events...)
+}
+
+// Reset removes all the recorded events
+func (m *Memory) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = nil
+}
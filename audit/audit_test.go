@@ -0,0 +1,97 @@
+package audit_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoenirvili/rester/audit"
+	"github.com/hoenirvili/rester/permission"
+)
+
+var event = audit.Event{
+	Time:     time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	Route:    "/users/{id}",
+	Method:   "DELETE",
+	Subject:  "alice",
+	Required: permission.Admin,
+	Held:     permission.Basic,
+	Decision: audit.Deny,
+	Reason:   "you don't have permission to access this resource",
+	ClientIP: "10.0.0.1",
+}
+
+func TestMemory(t *testing.T) {
+	require := require.New(t)
+	var m audit.Memory
+	require.Empty(m.Events())
+	m.Record(event)
+	m.Record(event)
+	require.Equal([]audit.Event{event, event}, m.Events())
+	m.Reset()
+	require.Empty(m.Events())
+}
+
+func TestWriter(t *testing.T) {
+	require := require.New(t)
+	var b bytes.Buffer
+	w := audit.NewWriter(&b)
+	w.Record(event)
+	w.Record(event)
+	require.NoError(w.Err())
+
+	lines := bytes.Split(bytes.TrimSpace(b.Bytes()), []byte("\n"))
+	require.Len(lines, 2)
+	require.JSONEq(`{
+		"time": "2020-01-01T00:00:00Z",
+		"route": "/users/{id}",
+		"method": "DELETE",
+		"subject": "alice",
+		"required": 8,
+		"held": 4,
+		"decision": "deny",
+		"reason": "you don't have permission to access this resource",
+		"client_ip": "10.0.0.1"
+	}`, string(lines[0]))
+	require.NoError(w.Close())
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestWriterErr(t *testing.T) {
+	w := audit.NewWriter(failingWriter{})
+	w.Record(event)
+	require.EqualError(t, w.Err(), "disk full")
+}
+
+func TestOpenFile(t *testing.T) {
+	require := require.New(t)
+	path := filepath.Join(t.TempDir(), "audit.log")
+	for i := 0; i < 2; i++ {
+		w, err := audit.OpenFile(path)
+		require.NoError(err)
+		w.Record(event)
+		require.NoError(w.Close())
+	}
+
+	f, err := os.Open(path)
+	require.NoError(err)
+	defer f.Close()
+	var events []audit.Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e audit.Event
+		require.NoError(json.Unmarshal(scanner.Bytes(), &e))
+		events = append(events, e)
+	}
+	require.Equal([]audit.Event{event, event}, events)
+}
@@ -52,7 +52,9 @@ func main() {
 		rester.WithTokenValidator(token),
 	)
 	rester.Resource("/", new(root))
-	rester.Build()
+	if err := rester.Build(); err != nil {
+		panic(err)
+	}
 	if err := http.ListenAndServe(":8080", rester); err != nil {
 		panic(err)
 	}
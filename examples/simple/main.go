@@ -32,7 +32,9 @@ func (r *root) Routes() route.Routes {
 func main() {
 	rester := rester.New()
 	rester.Resource("/", new(root))
-	rester.Build()
+	if err := rester.Build(); err != nil {
+		panic(err)
+	}
 	if err := http.ListenAndServe(":8080", rester); err != nil {
 		panic(err)
 	}
@@ -0,0 +1,242 @@
+// Package openapi used for describing rester resources as
+// an OpenAPI 3 document that can be served to the api clients
+package openapi
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/hoenirvili/rester/permission"
+	"github.com/hoenirvili/rester/query"
+	"github.com/hoenirvili/rester/route"
+	"github.com/hoenirvili/rester/value"
+)
+
+// Version is the OpenAPI specification version of the generated documents
+const Version = "3.0.3"
+
+// BearerAuth is the name of the security scheme used by secured routes
+const BearerAuth = "bearerAuth"
+
+// Document is the root object of an OpenAPI document
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components *Components         `json:"components,omitempty"`
+}
+
+// Info holds the metadata about the api
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem holds all operations of a path keyed by the lower case http method
+type PathItem map[string]*Operation
+
+// Operation describes a single api operation on a path
+type Operation struct {
+	Parameters []Parameter           `json:"parameters,omitempty"`
+	Responses  map[string]Response   `json:"responses"`
+	Security   []SecurityRequirement `json:"security,omitempty"`
+	// Permissions holds the permission bit flag required by the route
+	Permissions permission.Permissions `json:"x-permissions,omitempty"`
+	// Roles holds the names of the roles that can access the route
+	Roles []string `json:"x-roles,omitempty"`
+}
+
+// Parameter describes a single path or query parameter of an operation
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Style    string  `json:"style,omitempty"`
+	Explode  *bool   `json:"explode,omitempty"`
+	Schema   *Schema `json:"schema,omitempty"`
+}
+
+// Schema describes the type of a parameter
+type Schema struct {
+	Type     string      `json:"type,omitempty"`
+	Format   string      `json:"format,omitempty"`
+	Pattern  string      `json:"pattern,omitempty"`
+	Enum     []string    `json:"enum,omitempty"`
+	Items    *Schema     `json:"items,omitempty"`
+	Default  interface{} `json:"default,omitempty"`
+	MinItems int         `json:"minItems,omitempty"`
+	MaxItems int         `json:"maxItems,omitempty"`
+}
+
+// Response describes a single response of an operation
+type Response struct {
+	Description string `json:"description"`
+}
+
+// Components holds the reusable objects of the document
+type Components struct {
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme defines a security scheme used by the operations
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// SecurityRequirement lists the security schemes required by an operation
+type SecurityRequirement map[string][]string
+
+// New returns a new empty document with the given info
+func New(info Info) *Document {
+	return &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]PathItem),
+	}
+}
+
+// Add appends the route as an operation on the given chi url pattern
+// If secured is true the operation will require the bearer security scheme
+func (d *Document) Add(pattern string, r route.Route, secured bool) {
+	path, params := Path(pattern)
+	op := &Operation{
+		Parameters: params,
+		Responses: map[string]Response{
+			"default": {Description: "default response"},
+		},
+	}
+	op.Parameters = append(op.Parameters, Query(r.QueryPairs)...)
+	if secured {
+		d.secure()
+		op.Security = []SecurityRequirement{{BearerAuth: []string{}}}
+		op.Permissions = r.Allow
+		op.Roles = r.Roles
+	}
+
+	item, ok := d.Paths[path]
+	if !ok {
+		item = make(PathItem)
+		d.Paths[path] = item
+	}
+	item[strings.ToLower(r.Method)] = op
+}
+
+func (d *Document) secure() {
+	if d.Components == nil {
+		d.Components = &Components{}
+	}
+	if d.Components.SecuritySchemes == nil {
+		d.Components.SecuritySchemes = make(map[string]SecurityScheme)
+	}
+	d.Components.SecuritySchemes[BearerAuth] = SecurityScheme{
+		Type:         "http",
+		Scheme:       "bearer",
+		BearerFormat: "JWT",
+	}
+}
+
+// Path converts the chi url pattern into an OpenAPI path template
+// returning also all the path parameters found in the pattern
+func Path(pattern string) (string, []Parameter) {
+	var params []Parameter
+	segments := strings.Split(pattern, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+		name := segment[1 : len(segment)-1]
+		schema := &Schema{Type: "string"}
+		if n := strings.IndexByte(name, ':'); n >= 0 {
+			schema.Pattern = name[n+1:]
+			name = name[:n]
+		}
+		segments[i] = "{" + name + "}"
+		params = append(params, Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   schema,
+		})
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// Query converts the query pairs into OpenAPI query parameters
+func Query(pairs query.Pairs) []Parameter {
+	keys := make([]string, 0, len(pairs))
+	for key := range pairs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	params := make([]Parameter, 0, len(keys))
+	for _, key := range keys {
+		v := pairs[key]
+		param := Parameter{
+			Name:     v.URLKey(key),
+			In:       "query",
+			Required: v.Required,
+			Schema:   SchemaOf(v.Type),
+		}
+		if v.List != query.Single {
+			explode := v.List != query.Comma
+			param.Style = "form"
+			param.Explode = &explode
+			param.Schema = &Schema{
+				Type:     "array",
+				Items:    param.Schema,
+				MinItems: v.MinItems,
+				MaxItems: v.MaxItems,
+			}
+		}
+		if v.Default != "" {
+			param.Schema.Default = defaultOf(v)
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// SchemaOf returns the schema that describes the value type
+// based on the schema hint of the type
+func SchemaOf(t value.Type) *Schema {
+	hint := t.Schema()
+	if hint.Type == "" {
+		hint.Type = "string"
+	}
+	return &Schema{
+		Type:    hint.Type,
+		Format:  hint.Format,
+		Pattern: hint.Pattern,
+		Enum:    hint.Enum,
+	}
+}
+
+// defaultOf returns the default value of the query param
+// converted into the json type described by it's schema
+func defaultOf(v query.Value) interface{} {
+	inputs := []string{v.Default}
+	if v.List != query.Single {
+		inputs = strings.Split(v.Default, ",")
+	}
+	values := make([]interface{}, 0, len(inputs))
+	for _, input := range inputs {
+		var raw interface{} = input
+		parsed := value.Parse(input, v.Type)
+		if parsed.Error() != nil {
+			return nil
+		}
+		switch SchemaOf(v.Type).Type {
+		case "integer", "number", "boolean":
+			parsed.As(&raw)
+		}
+		values = append(values, raw)
+	}
+	if v.List == query.Single {
+		return values[0]
+	}
+	return values
+}
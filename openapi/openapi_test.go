@@ -0,0 +1,127 @@
+package openapi_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoenirvili/rester/openapi"
+	"github.com/hoenirvili/rester/permission"
+	"github.com/hoenirvili/rester/query"
+	"github.com/hoenirvili/rester/resource"
+	"github.com/hoenirvili/rester/route"
+	"github.com/hoenirvili/rester/value"
+)
+
+func TestPath(t *testing.T) {
+	require := require.New(t)
+	path, params := openapi.Path("/users/{id:[0-9]+}/posts/{post}")
+	require.Equal("/users/{id}/posts/{post}", path)
+	require.Equal([]openapi.Parameter{{
+		Name:     "id",
+		In:       "path",
+		Required: true,
+		Schema:   &openapi.Schema{Type: "string", Pattern: "[0-9]+"},
+	}, {
+		Name:     "post",
+		In:       "path",
+		Required: true,
+		Schema:   &openapi.Schema{Type: "string"},
+	}}, params)
+}
+
+func TestQuery(t *testing.T) {
+	require := require.New(t)
+	params := openapi.Query(query.Pairs{
+		"page": query.Value{Type: value.Int, Required: true},
+		"from": query.Value{Type: value.Date},
+	})
+	require.Equal([]openapi.Parameter{{
+		Name:   "from",
+		In:     "query",
+		Schema: &openapi.Schema{Type: "string", Format: "date"},
+	}, {
+		Name:     "page",
+		In:       "query",
+		Required: true,
+		Schema:   &openapi.Schema{Type: "integer", Format: "int32"},
+	}}, params)
+}
+
+func TestQueryList(t *testing.T) {
+	require := require.New(t)
+	params := openapi.Query(query.Pairs{
+		"ids":  query.Value{Type: value.Int64, List: query.Comma, MaxItems: 10},
+		"tags": query.Value{Type: value.String, List: query.Bracket},
+	})
+	explode, noExplode := true, false
+	require.Equal([]openapi.Parameter{{
+		Name:    "ids",
+		In:      "query",
+		Style:   "form",
+		Explode: &noExplode,
+		Schema: &openapi.Schema{
+			Type:     "array",
+			Items:    &openapi.Schema{Type: "integer", Format: "int64"},
+			MaxItems: 10,
+		},
+	}, {
+		Name:    "tags[]",
+		In:      "query",
+		Style:   "form",
+		Explode: &explode,
+		Schema: &openapi.Schema{
+			Type:  "array",
+			Items: &openapi.Schema{Type: "string"},
+		},
+	}}, params)
+}
+
+func TestQueryDefault(t *testing.T) {
+	require := require.New(t)
+	params := openapi.Query(query.Pairs{
+		"page": query.Value{Type: value.Int, Default: "1"},
+		"ids":  query.Value{Type: value.Uint64, List: query.Repeated, Default: "1,2"},
+	})
+	require.Len(params, 2)
+	require.Equal([]interface{}{uint64(1), uint64(2)}, params[0].Schema.Default)
+	require.Nil(params[0].Schema.Items.Default)
+	require.Equal(1, params[1].Schema.Default)
+}
+
+func TestAdd(t *testing.T) {
+	require := require.New(t)
+	doc := openapi.New(openapi.Info{Title: "test", Version: "1"})
+	doc.Add("/users/{id}", route.Route{
+		Method: resource.Get,
+		Allow:  permission.Admin,
+	}, true)
+	doc.Add("/users/{id}", route.Route{Method: resource.Delete}, false)
+
+	require.Contains(doc.Paths, "/users/{id}")
+	item := doc.Paths["/users/{id}"]
+	require.Contains(item, "get")
+	require.Contains(item, "delete")
+	require.Equal(permission.Admin, item["get"].Permissions)
+	require.Equal([]openapi.SecurityRequirement{{openapi.BearerAuth: []string{}}},
+		item["get"].Security)
+	require.Empty(item["delete"].Security)
+	require.NotNil(doc.Components)
+	require.Contains(doc.Components.SecuritySchemes, openapi.BearerAuth)
+}
+
+func TestSchemaOf(t *testing.T) {
+	require := require.New(t)
+	require.Equal(&openapi.Schema{Type: "boolean"}, openapi.SchemaOf(value.Bool))
+	require.Equal(&openapi.Schema{Type: "string", Format: "date-time"}, openapi.SchemaOf(value.Time))
+	require.Equal(&openapi.Schema{Type: "string", Format: "uuid"}, openapi.SchemaOf(value.UUID))
+	state := value.Enum("open", "closed")
+	require.Equal(&openapi.Schema{Type: "string", Enum: []string{"open", "closed"}},
+		openapi.SchemaOf(state))
+
+	version := value.Register("semver", func(input string) (interface{}, error) {
+		return input, nil
+	}, value.Schema{Pattern: `^\d+\.\d+\.\d+$`})
+	require.Equal(&openapi.Schema{Type: "string", Pattern: `^\d+\.\d+\.\d+$`},
+		openapi.SchemaOf(version))
+}
@@ -0,0 +1,32 @@
+package permission
+
+// Hierarchy maps a permission to the permissions it inherits directly
+// The inheritance is resolved transitively, so with
+// Hierarchy{Super: Admin, Admin: Basic} Super inherits also Basic
+type Hierarchy map[Permissions]Permissions
+
+// DefaultHierarchy is the hierarchy of the built-in permissions
+// where Super ⊇ Admin ⊇ Basic
+var DefaultHierarchy = Hierarchy{
+	Super: Admin,
+	Admin: Basic,
+}
+
+// Resolve returns p holding also all the permissions it inherits
+func (h Hierarchy) Resolve(p Permissions) Permissions {
+	if len(h) == 0 {
+		return p
+	}
+	for {
+		resolved := p
+		for parent, inherited := range h {
+			if p&parent == parent {
+				resolved |= inherited
+			}
+		}
+		if resolved == p {
+			return p
+		}
+		p = resolved
+	}
+}
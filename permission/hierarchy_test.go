@@ -0,0 +1,28 @@
+package permission_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoenirvili/rester/permission"
+)
+
+func TestHierarchyResolve(t *testing.T) {
+	require := require.New(t)
+	h := permission.DefaultHierarchy
+	require.Equal(permission.Super|permission.Admin|permission.Basic, h.Resolve(permission.Super))
+	require.Equal(permission.Admin|permission.Basic, h.Resolve(permission.Admin))
+	require.Equal(permission.Basic, h.Resolve(permission.Basic))
+	require.Equal(permission.Anonymous, h.Resolve(permission.Anonymous))
+
+	var empty permission.Hierarchy
+	require.Equal(permission.Super, empty.Resolve(permission.Super))
+
+	// cycles are resolved
+	cyclic := permission.Hierarchy{
+		permission.Basic: permission.Admin,
+		permission.Admin: permission.Basic,
+	}
+	require.Equal(permission.Admin|permission.Basic, cyclic.Resolve(permission.Basic))
+}
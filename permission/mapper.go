@@ -0,0 +1,92 @@
+package permission
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// Mapper resolves the permissions of the token claims
+type Mapper func(claims map[string]interface{}) (Permissions, error)
+
+// Bitmask returns a Mapper that reads the permissions from
+// the numeric bit flag found in the given claim
+// This is the default used by rester with the "permissions" claim
+func Bitmask(claim string) Mapper {
+	return func(claims map[string]interface{}) (Permissions, error) {
+		p, ok := claims[claim]
+		if !ok {
+			return NoPermission, errors.New("No '" + claim + "' key found in the token")
+		}
+		switch v := p.(type) {
+		case float64:
+			return Permissions(v), nil
+		case int:
+			return Permissions(v), nil
+		case json.Number:
+			n, err := v.Int64()
+			if err == nil {
+				return Permissions(n), nil
+			}
+		}
+		return NoPermission, errors.New("Invalid permission value")
+	}
+}
+
+// Roles returns a Mapper that combines the permissions of all the role
+// names found in the given claim, like "roles": ["admin", "billing"]
+// The claim can also hold the role names separated by spaces
+// Unknown roles are ignored and tokens without the claim have NoPermission
+func Roles(claim string, table map[string]Permissions) Mapper {
+	return func(claims map[string]interface{}) (Permissions, error) {
+		var list []string
+		switch v := claims[claim].(type) {
+		case nil:
+			return NoPermission, nil
+		case string:
+			list = strings.Fields(v)
+		case []string:
+			list = v
+		case []interface{}:
+			for _, name := range v {
+				s, ok := name.(string)
+				if !ok {
+					return NoPermission, errors.New("Invalid '" + claim + "' value, expected a list of strings")
+				}
+				list = append(list, s)
+			}
+		default:
+			return NoPermission, errors.New("Invalid '" + claim + "' value, expected a string or a list of strings")
+		}
+		return combine(list, table), nil
+	}
+}
+
+// Scopes returns a Mapper that combines the permissions of all the OAuth
+// scopes found in the given claim, a string holding the scopes separated
+// by spaces as defined by RFC 6749, like "scope": "read write"
+// Unknown scopes are ignored and tokens without the claim have NoPermission
+func Scopes(claim string, table map[string]Permissions) Mapper {
+	return func(claims map[string]interface{}) (Permissions, error) {
+		switch v := claims[claim].(type) {
+		case nil:
+			return NoPermission, nil
+		case string:
+			return combine(strings.Fields(v), table), nil
+		default:
+			return NoPermission, errors.New("Invalid '" + claim + "' value, expected a string of space separated scopes")
+		}
+	}
+}
+
+// combine combines the permissions of all the names found in the table
+func combine(names []string, table map[string]Permissions) Permissions {
+	var p Permissions
+	for _, name := range names {
+		p |= table[name]
+	}
+	if p == 0 {
+		return NoPermission
+	}
+	return p
+}
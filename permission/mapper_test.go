@@ -0,0 +1,75 @@
+package permission_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoenirvili/rester/permission"
+)
+
+func TestBitmask(t *testing.T) {
+	require := require.New(t)
+	m := permission.Bitmask("permissions")
+
+	p, err := m(map[string]interface{}{"permissions": float64(permission.Admin)})
+	require.NoError(err)
+	require.Equal(permission.Admin, p)
+
+	_, err = m(map[string]interface{}{})
+	require.EqualError(err, "No 'permissions' key found in the token")
+	_, err = m(map[string]interface{}{"permissions": "admin"})
+	require.EqualError(err, "Invalid permission value")
+}
+
+var table = map[string]permission.Permissions{
+	"admin":   permission.Admin,
+	"billing": permission.Basic,
+	"read":    permission.Basic,
+	"write":   permission.Admin,
+}
+
+func TestRoles(t *testing.T) {
+	require := require.New(t)
+	m := permission.Roles("roles", table)
+
+	p, err := m(map[string]interface{}{"roles": []interface{}{"admin", "billing", "unknown"}})
+	require.NoError(err)
+	require.Equal(permission.Admin|permission.Basic, p)
+
+	p, err = m(map[string]interface{}{"roles": "billing"})
+	require.NoError(err)
+	require.Equal(permission.Basic, p)
+
+	// the names can be separated by spaces, like RoleNames parses them
+	p, err = m(map[string]interface{}{"roles": "billing admin"})
+	require.NoError(err)
+	require.Equal(permission.Basic|permission.Admin, p)
+
+	p, err = m(map[string]interface{}{"roles": []interface{}{"unknown"}})
+	require.NoError(err)
+	require.Equal(permission.NoPermission, p)
+
+	p, err = m(map[string]interface{}{})
+	require.NoError(err)
+	require.Equal(permission.NoPermission, p)
+
+	_, err = m(map[string]interface{}{"roles": []interface{}{1}})
+	require.Error(err)
+	_, err = m(map[string]interface{}{"roles": 1.0})
+	require.Error(err)
+}
+
+func TestScopes(t *testing.T) {
+	require := require.New(t)
+	p, err := permission.Scopes("scope", table)(map[string]interface{}{"scope": "read  write"})
+	require.NoError(err)
+	require.Equal(permission.Basic|permission.Admin, p)
+
+	p, err = permission.Scopes("scope", table)(map[string]interface{}{})
+	require.NoError(err)
+	require.Equal(permission.NoPermission, p)
+
+	_, err = permission.Scopes("scp", table)(map[string]interface{}{"scp": []interface{}{"read"}})
+	require.Error(err)
+}
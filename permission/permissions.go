@@ -2,6 +2,8 @@
 // the permission of resources
 package permission
 
+import "sync"
+
 // Permissions holds a byte pattern of permissions
 type Permissions int
 
@@ -23,16 +25,21 @@ const (
 )
 
 // supportedPermission by default
-var supportedPermission = []Permissions{
+var supportedPermission = struct {
+	sync.RWMutex
+	list []Permissions
+}{list: []Permissions{
 	Anonymous,
 	Basic,
 	Admin,
 	Super,
-}
+}}
 
 // Valid checks if the given permission is valid and supported
 func (p Permissions) Valid() bool {
-	for _, permission := range supportedPermission {
+	supportedPermission.RLock()
+	defer supportedPermission.RUnlock()
+	for _, permission := range supportedPermission.list {
 		if permission == p {
 			return true
 		}
@@ -42,6 +49,24 @@ func (p Permissions) Valid() bool {
 
 // Append appends a new permission in the underlying supported permissions
 // The newly permission added now can be used in the route guard API
+// Append is safe for concurrent use, use Register for named roles
 func Append(p Permissions) {
-	supportedPermission = append(supportedPermission, p)
+	supportedPermission.Lock()
+	defer supportedPermission.Unlock()
+	supportedPermission.list = append(supportedPermission.list, p)
+}
+
+// builtin maps the names of the built-in permissions
+var builtin = map[string]Permissions{
+	"anonymous": Anonymous,
+	"basic":     Basic,
+	"admin":     Admin,
+	"super":     Super,
+}
+
+// Named returns the built-in permission with the given name
+// e.g. "anonymous", "basic", "admin" or "super"
+func Named(name string) (Permissions, bool) {
+	p, ok := builtin[name]
+	return p, ok
 }
@@ -0,0 +1,217 @@
+package permission
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Role is a named role registered using Register
+// Unlike Permissions, the number of roles is not limited
+type Role uint
+
+// roles holds all registered roles, a role is the index of it's name
+var roles = struct {
+	sync.RWMutex
+	names []string
+	index map[string]Role
+}{index: make(map[string]Role)}
+
+// Register registers a new role with the given name returning it
+// Register should be called at startup, before building the router
+// It panics if the name is empty, contains spaces or is already registered
+func Register(name string) Role {
+	if name == "" || strings.ContainsAny(name, " \t\n") {
+		panic(fmt.Sprintf("permission: invalid role name %q", name))
+	}
+	roles.Lock()
+	defer roles.Unlock()
+	if _, ok := roles.index[name]; ok {
+		panic(fmt.Sprintf("permission: role %q already registered", name))
+	}
+	r := Role(len(roles.names))
+	roles.names = append(roles.names, name)
+	roles.index[name] = r
+	return r
+}
+
+// Lookup returns the registered role with the given name
+func Lookup(name string) (Role, bool) {
+	roles.RLock()
+	defer roles.RUnlock()
+	r, ok := roles.index[name]
+	return r, ok
+}
+
+// String returns the name of the role
+func (r Role) String() string {
+	roles.RLock()
+	defer roles.RUnlock()
+	if int(r) < len(roles.names) {
+		return roles.names[r]
+	}
+	return fmt.Sprintf("Role(%d)", uint(r))
+}
+
+// Set is a set of roles, the zero value is an empty set
+type Set struct {
+	words []uint64
+}
+
+// NewSet returns a set holding the given roles
+func NewSet(roles ...Role) Set {
+	return Set{}.Add(roles...)
+}
+
+// ParseSet returns a set holding the roles with the given names
+// It fails if any name is not registered
+func ParseSet(names ...string) (Set, error) {
+	var s Set
+	for _, name := range names {
+		r, ok := Lookup(name)
+		if !ok {
+			return Set{}, fmt.Errorf("unknown role %q", name)
+		}
+		s = s.Add(r)
+	}
+	return s, nil
+}
+
+// Add returns a new set holding the roles of s and the given ones
+func (s Set) Add(roles ...Role) Set {
+	words := append([]uint64(nil), s.words...)
+	for _, r := range roles {
+		i := int(r / 64)
+		for len(words) <= i {
+			words = append(words, 0)
+		}
+		words[i] |= 1 << (r % 64)
+	}
+	return Set{words}
+}
+
+// Has reports if the set holds the role
+func (s Set) Has(r Role) bool {
+	i := int(r / 64)
+	return i < len(s.words) && s.words[i]&(1<<(r%64)) != 0
+}
+
+// Empty reports if the set holds no roles
+func (s Set) Empty() bool {
+	for _, w := range s.words {
+		if w != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Any reports if s holds at least one role of o
+func (s Set) Any(o Set) bool {
+	for i := 0; i < len(s.words) && i < len(o.words); i++ {
+		if s.words[i]&o.words[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// All reports if s holds all the roles of o
+func (s Set) All(o Set) bool {
+	for i, w := range o.words {
+		var have uint64
+		if i < len(s.words) {
+			have = s.words[i]
+		}
+		if have&w != w {
+			return false
+		}
+	}
+	return true
+}
+
+// Roles returns all the roles of the set in ascending order
+func (s Set) Roles() []Role {
+	var list []Role
+	for i, w := range s.words {
+		for w != 0 {
+			bit := bits.TrailingZeros64(w)
+			list = append(list, Role(i*64+bit))
+			w &^= 1 << bit
+		}
+	}
+	return list
+}
+
+// Names returns the names of all the roles of the set sorted
+func (s Set) Names() []string {
+	list := s.Roles()
+	names := make([]string, 0, len(list))
+	for _, r := range list {
+		names = append(names, r.String())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MarshalJSON marshals the set as a list of role names
+func (s Set) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Names())
+}
+
+// UnmarshalJSON unmarshals a list of registered role names into the set
+func (s *Set) UnmarshalJSON(b []byte) error {
+	var names []string
+	if err := json.Unmarshal(b, &names); err != nil {
+		return err
+	}
+	set, err := ParseSet(names...)
+	if err != nil {
+		return err
+	}
+	*s = set
+	return nil
+}
+
+// RoleMapper resolves the roles of the token claims
+type RoleMapper func(claims map[string]interface{}) (Set, error)
+
+// RoleNames returns a RoleMapper that reads the role names found
+// in the given claim, like "roles": ["admin", "billing"]
+// The claim can also hold the names separated by spaces
+// Unknown role names are ignored
+func RoleNames(claim string) RoleMapper {
+	return func(claims map[string]interface{}) (Set, error) {
+		var list []string
+		switch v := claims[claim].(type) {
+		case nil:
+			return Set{}, nil
+		case string:
+			list = strings.Fields(v)
+		case []string:
+			list = v
+		case []interface{}:
+			for _, name := range v {
+				s, ok := name.(string)
+				if !ok {
+					return Set{}, errors.New("Invalid '" + claim + "' value, expected a list of strings")
+				}
+				list = append(list, s)
+			}
+		default:
+			return Set{}, errors.New("Invalid '" + claim + "' value, expected a string or a list of strings")
+		}
+
+		var s Set
+		for _, name := range list {
+			if r, ok := Lookup(name); ok {
+				s = s.Add(r)
+			}
+		}
+		return s, nil
+	}
+}
@@ -0,0 +1,117 @@
+package permission_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoenirvili/rester/permission"
+)
+
+func TestRegister(t *testing.T) {
+	require := require.New(t)
+	r := permission.Register("test-register")
+	require.Equal("test-register", r.String())
+	got, ok := permission.Lookup("test-register")
+	require.True(ok)
+	require.Equal(r, got)
+	_, ok = permission.Lookup("test-missing")
+	require.False(ok)
+
+	require.Panics(func() { permission.Register("test-register") })
+	require.Panics(func() { permission.Register("") })
+	require.Panics(func() { permission.Register("test role") })
+}
+
+func TestRegisterConcurrent(t *testing.T) {
+	require := require.New(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			permission.Register(fmt.Sprintf("test-concurrent-%d", i))
+			permission.Append(permission.Permissions(1 << 10))
+			permission.Permissions(1 << 10).Valid()
+		}(i)
+	}
+	wg.Wait()
+	for i := 0; i < 100; i++ {
+		_, ok := permission.Lookup(fmt.Sprintf("test-concurrent-%d", i))
+		require.True(ok)
+	}
+}
+
+func TestSet(t *testing.T) {
+	require := require.New(t)
+	var many []permission.Role
+	for i := 0; i < 70; i++ {
+		many = append(many, permission.Register(fmt.Sprintf("test-set-%02d", i)))
+	}
+	first, last := many[0], many[69]
+
+	var empty permission.Set
+	require.True(empty.Empty())
+	require.False(empty.Has(first))
+
+	s := permission.NewSet(first, last)
+	require.False(s.Empty())
+	require.True(s.Has(first))
+	require.True(s.Has(last))
+	require.False(s.Has(many[1]))
+	require.Equal([]permission.Role{first, last}, s.Roles())
+
+	require.True(s.Any(permission.NewSet(last, many[2])))
+	require.False(s.Any(permission.NewSet(many[2])))
+	require.False(s.Any(empty))
+	require.True(s.All(permission.NewSet(last)))
+	require.False(s.All(permission.NewSet(last, many[2])))
+	require.True(s.All(empty))
+	require.False(empty.All(s))
+
+	// Add does not modify the original set
+	more := s.Add(many[2])
+	require.True(more.Has(many[2]))
+	require.False(s.Has(many[2]))
+}
+
+func TestSetJSON(t *testing.T) {
+	require := require.New(t)
+	a := permission.Register("test-json-a")
+	b := permission.Register("test-json-b")
+
+	data, err := json.Marshal(permission.NewSet(b, a))
+	require.NoError(err)
+	require.JSONEq(`["test-json-a","test-json-b"]`, string(data))
+
+	var s permission.Set
+	require.NoError(json.Unmarshal([]byte(`["test-json-b"]`), &s))
+	require.True(s.Has(b))
+	require.False(s.Has(a))
+	require.Error(json.Unmarshal([]byte(`["test-json-unknown"]`), &s))
+
+	set, err := permission.ParseSet("test-json-a", "test-json-b")
+	require.NoError(err)
+	require.Equal([]string{"test-json-a", "test-json-b"}, set.Names())
+}
+
+func TestRoleNames(t *testing.T) {
+	require := require.New(t)
+	a := permission.Register("test-names-a")
+	m := permission.RoleNames("roles")
+
+	s, err := m(map[string]interface{}{"roles": []interface{}{"test-names-a", "unknown"}})
+	require.NoError(err)
+	require.Equal([]permission.Role{a}, s.Roles())
+	s, err = m(map[string]interface{}{"roles": "test-names-a"})
+	require.NoError(err)
+	require.True(s.Has(a))
+	s, err = m(map[string]interface{}{})
+	require.NoError(err)
+	require.True(s.Empty())
+	_, err = m(map[string]interface{}{"roles": 1.0})
+	require.Error(err)
+}
@@ -0,0 +1,172 @@
+// Package policy offers attribute-based authorization policies
+// that can be attached to routes and composed together
+//
+//	route.Route{
+//		URL:    "/{id}",
+//		Method: resource.Put,
+//		Allow:  permission.Basic | permission.Admin,
+//		Policy: policy.AnyOf(
+//			policy.OwnerOf("id", "sub"),
+//			policy.HasPermission(permission.Admin),
+//		),
+//	}
+package policy
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hoenirvili/rester/permission"
+	"github.com/hoenirvili/rester/request"
+	"github.com/hoenirvili/rester/value"
+)
+
+// Reason codes of the denials returned by the built-in policies
+const (
+	// CodeDenied is used by Not and by policies that fail with a plain error
+	CodeDenied = "denied"
+	// CodeNotOwner is used by OwnerOf
+	CodeNotOwner = "not_owner"
+	// CodeMissingPermission is used by HasPermission
+	CodeMissingPermission = "missing_permission"
+	// CodeMissingRole is used by HasRole
+	CodeMissingRole = "missing_role"
+)
+
+// Denial describes why a policy denied the request
+type Denial struct {
+	// Code is a machine-readable reason code, like "not_owner"
+	Code string `json:"code"`
+	// Reason is a human-readable explanation of the denial
+	Reason string `json:"reason"`
+}
+
+// Error returns the reason of the denial
+func (d *Denial) Error() string { return d.Reason }
+
+// Deny returns a new denial with the given code and reason
+func Deny(code, reason string) *Denial {
+	return &Denial{Code: code, Reason: reason}
+}
+
+// DenialOf returns the denial held by err
+// Errors that are not denials are reported with CodeDenied
+func DenialOf(err error) *Denial {
+	var d *Denial
+	if errors.As(err, &d) {
+		return d
+	}
+	return Deny(CodeDenied, err.Error())
+}
+
+// Policy decides if a request can access a route
+type Policy interface {
+	// Evaluate returns nil if the request is allowed,
+	// otherwise a *Denial explaining why it's not
+	Evaluate(req request.Request) error
+}
+
+// Func is an adapter to allow the use of ordinary functions as a Policy
+type Func func(req request.Request) error
+
+// Evaluate calls f(req)
+func (f Func) Evaluate(req request.Request) error { return f(req) }
+
+// AllOf allows the request only if all the policies allow it
+// The first denial is returned
+func AllOf(policies ...Policy) Policy {
+	return Func(func(req request.Request) error {
+		for _, p := range policies {
+			if err := p.Evaluate(req); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// AnyOf allows the request if at least one of the policies allows it
+// If all of them deny the request the last denial is returned
+func AnyOf(policies ...Policy) Policy {
+	return Func(func(req request.Request) error {
+		err := error(Deny(CodeDenied, "no policy allows the request"))
+		for _, p := range policies {
+			if err = p.Evaluate(req); err == nil {
+				return nil
+			}
+		}
+		return err
+	})
+}
+
+// Not allows the request only if the policy denies it
+// The returned denial has the given code and reason
+// Errors that are not a Denial, like a failing database, are returned
+// as they are so the request is still denied
+func Not(p Policy, code, reason string) Policy {
+	return Func(func(req request.Request) error {
+		err := p.Evaluate(req)
+		if err == nil {
+			return Deny(code, reason)
+		}
+		var d *Denial
+		if errors.As(err, &d) {
+			return nil
+		}
+		return err
+	})
+}
+
+// claimString returns the claim formatted as a string
+func claimString(req request.Request, claim string) (string, bool) {
+	c := req.Claims()
+	if c == nil {
+		return "", false
+	}
+	switch v := c.Raw[claim].(type) {
+	case string:
+		return v, v != ""
+	case float64:
+		return fmt.Sprintf("%.0f", v), v == float64(int64(v))
+	case nil:
+		return "", false
+	default:
+		return fmt.Sprint(v), true
+	}
+}
+
+// OwnerOf allows the request only if the url param is equal
+// to the claim of the request token, like OwnerOf("id", "sub")
+func OwnerOf(urlParam, claim string) Policy {
+	return Func(func(req request.Request) error {
+		param := req.URLParam(urlParam, value.String).String()
+		owner, ok := claimString(req, claim)
+		if !ok || param == "" || param != owner {
+			return Deny(CodeNotOwner, "you don't own this resource")
+		}
+		return nil
+	})
+}
+
+// HasPermission allows the request only if the request
+// token has any of the permission bits of p
+func HasPermission(p permission.Permissions) Policy {
+	return Func(func(req request.Request) error {
+		if req.Permission()&p == 0 {
+			return Deny(CodeMissingPermission, "you don't have permission to access this resource")
+		}
+		return nil
+	})
+}
+
+// HasRole allows the request only if the request token has the role
+// The role must be registered using permission.Register
+func HasRole(name string) Policy {
+	return Func(func(req request.Request) error {
+		r, ok := permission.Lookup(name)
+		if !ok || !req.Roles().Has(r) {
+			return Deny(CodeMissingRole, "you don't have the role to access this resource")
+		}
+		return nil
+	})
+}
@@ -0,0 +1,81 @@
+package policy_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoenirvili/rester/permission"
+	"github.com/hoenirvili/rester/policy"
+	"github.com/hoenirvili/rester/request"
+)
+
+var editor = permission.Register("policy-editor")
+
+func newRequest(id string, claims map[string]interface{}, p permission.Permissions) request.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	req := new(http.Request)
+	req = req.WithContext(context.WithValue(context.Background(), chi.RouteCtxKey, rctx))
+	if claims != nil {
+		c := request.NewClaims(claims)
+		c.Permissions = p
+		c.Roles = permission.NewSet(editor)
+		req = request.WithClaims(req, c)
+	}
+	return request.New(req, nil)
+}
+
+func code(err error) string {
+	if err == nil {
+		return ""
+	}
+	return policy.DenialOf(err).Code
+}
+
+func TestOwnerOf(t *testing.T) {
+	require := require.New(t)
+	p := policy.OwnerOf("id", "sub")
+	require.NoError(p.Evaluate(newRequest("alice", map[string]interface{}{"sub": "alice"}, 0)))
+	require.Equal(policy.CodeNotOwner, code(p.Evaluate(newRequest("bob", map[string]interface{}{"sub": "alice"}, 0))))
+	require.Equal(policy.CodeNotOwner, code(p.Evaluate(newRequest("alice", nil, 0))))
+	require.Equal(policy.CodeNotOwner, code(p.Evaluate(newRequest("", map[string]interface{}{"sub": ""}, 0))))
+
+	p = policy.OwnerOf("id", "uid")
+	require.NoError(p.Evaluate(newRequest("42", map[string]interface{}{"uid": float64(42)}, 0)))
+}
+
+func TestCombinators(t *testing.T) {
+	require := require.New(t)
+	owner := policy.OwnerOf("id", "sub")
+	admin := policy.HasPermission(permission.Admin)
+	claims := map[string]interface{}{"sub": "alice"}
+
+	p := policy.AnyOf(owner, admin)
+	require.NoError(p.Evaluate(newRequest("alice", claims, permission.Basic)))
+	require.NoError(p.Evaluate(newRequest("bob", claims, permission.Admin)))
+	require.Equal(policy.CodeMissingPermission, code(p.Evaluate(newRequest("bob", claims, permission.Basic))))
+	require.Equal(policy.CodeDenied, code(policy.AnyOf().Evaluate(newRequest("bob", claims, 0))))
+
+	p = policy.AllOf(owner, policy.HasRole("policy-editor"))
+	require.NoError(p.Evaluate(newRequest("alice", claims, 0)))
+	require.Equal(policy.CodeNotOwner, code(p.Evaluate(newRequest("bob", claims, 0))))
+	require.Equal(policy.CodeMissingRole, code(policy.HasRole("unknown").Evaluate(newRequest("alice", claims, 0))))
+
+	p = policy.Not(admin, "admins_only_read", "admins can only read")
+	require.NoError(p.Evaluate(newRequest("alice", claims, permission.Basic)))
+	err := p.Evaluate(newRequest("alice", claims, permission.Admin))
+	require.Equal("admins_only_read", code(err))
+	require.EqualError(err, "admins can only read")
+
+	p = policy.Func(func(request.Request) error { return errors.New("closed") })
+	require.Equal(policy.CodeDenied, code(p.Evaluate(newRequest("alice", claims, 0))))
+
+	// only denials are inverted, other errors still deny the request
+	err = policy.Not(p, "open", "the store is open").Evaluate(newRequest("alice", claims, 0))
+	require.EqualError(err, "closed")
+}
@@ -5,15 +5,50 @@ package query
 import (
 	"errors"
 	"net/url"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/hoenirvili/rester/value"
 )
 
+// List defines the way a query param holds a list of values
+type List uint8
+
+const (
+	// Single means the query param holds only one value, ?id=1
+	Single List = iota
+	// Repeated means the key is repeated for every value, ?ids=1&ids=2
+	Repeated
+	// Comma means the values are separated by commas, ?ids=1,2
+	Comma
+	// Bracket means the key is suffixed with brackets and repeated
+	// for every value, ?ids[]=1&ids[]=2
+	Bracket
+)
+
 // Value type holds information about query values in url paramas
 type Value struct {
 	// Type is the type of value of the query param
+	// If the query param is a list this is the type of every element
 	Type value.Type
 
+	// List declares if and how the query param holds a list of values
+	List List
+
+	// MinItems is the minimum number of elements a list should have
+	MinItems int
+
+	// MaxItems is the maximum number of elements a list can have
+	// If this is 0 the list can have any number of elements
+	MaxItems int
+
+	// Default is the raw value used when the query param is not
+	// present in the request url, it's parsed the same way as a value
+	// found in the url would be parsed
+	// For lists, the default value holds all elements separated by commas
+	Default string
+
 	// Required is true if the query URL is required to be present
 	// in the request url
 	// If the query param is not present then this will automatically
@@ -34,6 +69,29 @@ func (p Pairs) panicCheckKey(key string) {
 	}
 }
 
+// URLKey returns the key used in the url query for the given pair key
+func (v Value) URLKey(key string) string {
+	if v.List == Bracket {
+		return key + "[]"
+	}
+	return key
+}
+
+// Inputs returns all raw inputs of the key from the url values
+// based on the way the query param was declared
+func (p Pairs) Inputs(key string, values url.Values) []string {
+	v := p[key]
+	inputs := values[v.URLKey(key)]
+	if v.List != Comma {
+		return inputs
+	}
+	var split []string
+	for _, input := range inputs {
+		split = append(split, strings.Split(input, ",")...)
+	}
+	return split
+}
+
 func (p Pairs) Parse(key string, values url.Values) error {
 	p.panicCheckKey(key)
 
@@ -41,6 +99,10 @@ func (p Pairs) Parse(key string, values url.Values) error {
 		return errors.New("cannot parse an empty url query map")
 	}
 
+	if p[key].List != Single {
+		return p.parseList(key, values)
+	}
+
 	queryValue := values[key]
 	switch len(queryValue) {
 	case 0:
@@ -49,7 +111,86 @@ func (p Pairs) Parse(key string, values url.Values) error {
 		value := value.Parse(queryValue[0], p[key].Type)
 		return value.Error()
 	default:
-		//TODO(hoenir): Maybe add this functionalty in the future
-		return errors.New("not implemented, cannot parse arrays")
+		return errors.New("the query param " + key + " accepts only one value")
+	}
+}
+
+// Error describes a query param that is not valid
+type Error struct {
+	// Param is the key of the query param
+	Param string `json:"param"`
+	// Message is a human-readable explanation of the problem
+	Message string `json:"message"`
+}
+
+// Error returns the query param key and the problem message
+func (e Error) Error() string { return e.Param + ": " + e.Message }
+
+// Errors holds all query params that are not valid
+type Errors []Error
+
+// Error returns all query param errors separated by "; "
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Validate validates all the query pairs, required or not, against
+// the url values returning Errors containing every param that's not valid
+// Optional params that are not present in the url are considered valid
+func (p Pairs) Validate(values url.Values) error {
+	keys := make([]string, 0, len(p))
+	for key := range p {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs Errors
+	for _, key := range keys {
+		if len(p.Inputs(key, values)) == 0 {
+			if p[key].Required {
+				errs = append(errs, Error{key, "the query param is required"})
+			}
+			continue
+		}
+		if err := p.Parse(key, values); err != nil {
+			errs = append(errs, Error{key, err.Error()})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ParseDefault parses the default value of the key
+func (p Pairs) ParseDefault(key string) value.Value {
+	v := p[key]
+	if v.List != Single {
+		if v.Default == "" {
+			return value.ParseList(nil, v.Type)
+		}
+		return value.ParseList(strings.Split(v.Default, ","), v.Type)
+	}
+	return value.Parse(v.Default, v.Type)
+}
+
+func (p Pairs) parseList(key string, values url.Values) error {
+	inputs := p.Inputs(key, values)
+	if len(inputs) == 0 {
+		return errors.New("cannot parse an empty url query values map")
+	}
+	v := p[key]
+	if len(inputs) < v.MinItems {
+		return errors.New("the query param " + key + " requires at least " +
+			strconv.Itoa(v.MinItems) + " values")
+	}
+	if v.MaxItems > 0 && len(inputs) > v.MaxItems {
+		return errors.New("the query param " + key + " accepts at most " +
+			strconv.Itoa(v.MaxItems) + " values")
 	}
+	return value.ParseList(inputs, v.Type).Error()
 }
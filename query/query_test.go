@@ -58,3 +58,96 @@ func TestPairParseWithError(t *testing.T) {
 	err := p.Parse("test", url.Values{"test": []string{"anothertestt", "onemore"}})
 	require.Error(err)
 }
+
+func TestPairParseList(t *testing.T) {
+	require := require.New(t)
+	tests := []struct {
+		value  query.Value
+		values url.Values
+	}{
+		{query.Value{Type: value.Int, List: query.Repeated}, url.Values{"ids": {"1", "2"}}},
+		{query.Value{Type: value.Int, List: query.Comma}, url.Values{"ids": {"1,2", "3"}}},
+		{query.Value{Type: value.Int, List: query.Bracket}, url.Values{"ids[]": {"1", "2"}}},
+		{query.Value{Type: value.Int, List: query.Repeated, MinItems: 1, MaxItems: 2}, url.Values{"ids": {"1"}}},
+	}
+	for _, test := range tests {
+		p := query.Pairs{"ids": test.value}
+		err := p.Parse("ids", test.values)
+		require.NoError(err)
+	}
+}
+
+func TestPairParseListWithError(t *testing.T) {
+	require := require.New(t)
+	tests := []struct {
+		value  query.Value
+		values url.Values
+	}{
+		{query.Value{Type: value.Int, List: query.Repeated}, url.Values{"ids": {"1", "a"}}},
+		{query.Value{Type: value.Int, List: query.Comma}, url.Values{"ids": {"1,,2"}}},
+		{query.Value{Type: value.Int, List: query.Bracket}, url.Values{"ids": {"1", "2"}}},
+		{query.Value{Type: value.Int, List: query.Repeated, MinItems: 2}, url.Values{"ids": {"1"}}},
+		{query.Value{Type: value.Int, List: query.Repeated, MaxItems: 1}, url.Values{"ids": {"1", "2"}}},
+	}
+	for _, test := range tests {
+		p := query.Pairs{"ids": test.value}
+		err := p.Parse("ids", test.values)
+		require.Error(err)
+	}
+}
+
+func TestPairInputs(t *testing.T) {
+	require := require.New(t)
+	p := query.Pairs{
+		"single":   query.Value{},
+		"comma":    query.Value{List: query.Comma},
+		"brackets": query.Value{List: query.Bracket},
+	}
+	values := url.Values{
+		"single":     {"a"},
+		"comma":      {"a,b", "c"},
+		"brackets[]": {"a", "b"},
+	}
+	require.Equal([]string{"a"}, p.Inputs("single", values))
+	require.Equal([]string{"a", "b", "c"}, p.Inputs("comma", values))
+	require.Equal([]string{"a", "b"}, p.Inputs("brackets", values))
+	require.Empty(p.Inputs("unknown", values))
+}
+
+func TestPairsValidate(t *testing.T) {
+	require := require.New(t)
+	p := query.Pairs{
+		"page":  query.Value{Type: value.Int, Required: true},
+		"limit": query.Value{Type: value.Int},
+		"from":  query.Value{Type: value.Date},
+		"ids":   query.Value{Type: value.Int, List: query.Comma},
+		"sort":  query.Value{Type: value.String},
+	}
+	err := p.Validate(url.Values{
+		"limit": {"ten"},
+		"from":  {"2020-01-01"},
+		"ids":   {"1,a"},
+		"sort":  {"asc", "desc"},
+	})
+	require.Equal(query.Errors{
+		{Param: "ids", Message: `cannot parse the given input "a" into Int`},
+		{Param: "limit", Message: `cannot parse the given input "ten" into Int`},
+		{Param: "page", Message: "the query param is required"},
+		{Param: "sort", Message: "the query param sort accepts only one value"},
+	}, err)
+
+	err = p.Validate(url.Values{"page": {"1"}})
+	require.NoError(err)
+}
+
+func TestPairsParseDefault(t *testing.T) {
+	require := require.New(t)
+	p := query.Pairs{
+		"page":  query.Value{Type: value.Int, Default: "1"},
+		"ids":   query.Value{Type: value.Int, List: query.Repeated, Default: "1,2"},
+		"limit": query.Value{Type: value.Int},
+	}
+	require.Equal(1, p.ParseDefault("page").Int())
+	require.Equal([]int{1, 2}, p.ParseDefault("ids").Ints())
+	require.Equal(value.ErrNoValueFound, p.ParseDefault("limit").Error())
+}
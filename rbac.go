@@ -0,0 +1,142 @@
+package rester
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/hoenirvili/rester/rbac"
+	"github.com/hoenirvili/rester/route"
+)
+
+// WithRBAC applies the access rules of the policy over the routes
+// A rule replaces the Allow, Roles and RequireAll fields of the route it matches
+// The policy can be replaced after Build using ReloadPolicy
+func WithRBAC(p *rbac.Policy) Option {
+	return func(opts *Options) { opts.rbac = p }
+}
+
+// policyState holds a compiled rbac policy
+type policyState struct {
+	// rules holds the access declared by the rules of the policy
+	rules map[string]rbac.Access
+	// access holds the checks of every route resolved from the rules
+	access map[string]access
+}
+
+// state returns the current compiled policy, nil before Build or without WithRBAC
+func (r *Rester) state() *policyState {
+	s, _ := r.config.policy.Load().(*policyState)
+	return s
+}
+
+// override returns the route with the access declared
+// by the current rbac rule found under key, if any
+func (r *Rester) override(key string, route route.Route) route.Route {
+	s := r.state()
+	if s == nil {
+		return route
+	}
+	if a, ok := s.rules[key]; ok {
+		route.Allow = a.Allow
+		route.Roles = a.Roles
+		route.RequireAll = a.RequireAll
+	}
+	return route
+}
+
+// currentAccess returns the checks of the route resolved from the current policy
+func (r *Rester) currentAccess(key string) access {
+	return r.state().access[key]
+}
+
+// compilePolicy resolves the checks of every route using the rules of the policy
+// It fails if a rule is not valid or does not match any route
+func (r *Rester) compilePolicy(p *rbac.Policy) (*policyState, BuildError) {
+	rules, err := p.Compile()
+	if err != nil {
+		return nil, BuildError{{Err: err}}
+	}
+
+	bases := make([]string, 0, len(r.config.resources))
+	for base := range r.config.resources {
+		bases = append(bases, base)
+	}
+	sort.Strings(bases)
+
+	compiled := make(map[string]access)
+	for _, base := range bases {
+		res := r.config.resources[base]
+		if res == nil {
+			continue
+		}
+		for _, route := range res.Routes() {
+			key := rbac.Key(base, route.Method, route.URL)
+			if a, ok := rules[key]; ok {
+				route.Allow = a.Allow
+				route.Roles = a.Roles
+				route.RequireAll = a.RequireAll
+			}
+			compiled[key] = r.accessOf(normalize(route))
+		}
+	}
+
+	var errs BuildError
+	for _, rule := range p.Rules {
+		if _, ok := compiled[rule.Key()]; !ok {
+			errs = append(errs, &ConfigError{
+				Resource: rule.Resource,
+				Method:   rule.Method,
+				URL:      rule.URL,
+				Err:      errors.New("the rbac rule does not match any route"),
+			})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return &policyState{rules, compiled}, nil
+}
+
+// ReloadPolicy validates the policy and atomically replaces the
+// current one, the requests already being served are not affected
+// The OpenAPI document served by WithOpenAPI is regenerated from the new policy
+// A policy cannot make an anonymous route secured or the other way around
+// because the token validation is decided when the route is mounted
+func (r *Rester) ReloadPolicy(p *rbac.Policy) error {
+	r.config.reload.Lock()
+	defer r.config.reload.Unlock()
+
+	current := r.state()
+	if current == nil || r.options.rbac == nil {
+		return errors.New("rester: cannot reload the policy before Build or without WithRBAC")
+	}
+	if p == nil {
+		return errors.New("rester: cannot reload a nil policy")
+	}
+	next, errs := r.compilePolicy(p)
+	if errs != nil {
+		return errs
+	}
+
+	keys := make([]string, 0, len(next.access))
+	for key := range next.access {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if next.access[key].anonymous != current.access[key].anonymous {
+			errs = append(errs, &ConfigError{
+				Err: fmt.Errorf("route %s cannot change from or to anonymous", key),
+			})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	r.config.policy.Store(next)
+	if r.options.openapi != nil {
+		r.config.openapi.Store(r.OpenAPI())
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+// Package rbac loads declarative role based access rules that
+// override the permissions declared by the rester resources
+//
+// The rules are written in JSON, every rule matches a single route by
+// the base of it's resource, it's http method and it's relative URL:
+//
+//	{
+//		"rules": [
+//			{"resource": "/users", "method": "GET", "url": "/", "roles": ["basic", "admin"]},
+//			{"resource": "/users", "method": "DELETE", "url": "/{id}", "roles": ["admin", "support"]},
+//			{"resource": "/reports", "method": "GET", "url": "/", "roles": ["admin", "billing"], "require_all": true}
+//		]
+//	}
+//
+// The roles "anonymous", "basic", "admin" and "super" are the built-in
+// permissions, all other roles must be registered using permission.Register
+package rbac
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/hoenirvili/rester/permission"
+)
+
+// Rule defines who can access a single route
+type Rule struct {
+	// Resource is the base the resource was appended with
+	Resource string `json:"resource"`
+	// Method is the http method of the route
+	Method string `json:"method"`
+	// URL is the relative URL of the route as declared by the resource
+	URL string `json:"url"`
+	// Roles holds the names of the roles that can access the route
+	Roles []string `json:"roles"`
+	// RequireAll is true if the token must have all the roles
+	RequireAll bool `json:"require_all,omitempty"`
+}
+
+// Key returns the key identifying the route of the rule
+func (r Rule) Key() string {
+	return Key(r.Resource, r.Method, r.URL)
+}
+
+// Key returns the key identifying a route of a resource
+func Key(resource, method, url string) string {
+	return strings.ToUpper(method) + " " + resource + " " + url
+}
+
+// Access is the resolved access of a route
+type Access struct {
+	// Allow holds the built-in permissions of the rule
+	Allow permission.Permissions
+	// Roles holds the names of the registered roles of the rule
+	Roles []string
+	// RequireAll is true if the token must have all the roles
+	RequireAll bool
+}
+
+// Anonymous reports if anyone can access the route
+func (a Access) Anonymous() bool {
+	return a.Allow&permission.Anonymous != 0
+}
+
+// Resolve splits the roles of the rule into built-in permissions and registered roles
+// It fails if a role is neither a built-in permission nor a registered role
+func (r Rule) Resolve() (Access, error) {
+	a := Access{RequireAll: r.RequireAll}
+	for _, name := range r.Roles {
+		if p, ok := permission.Named(name); ok {
+			a.Allow |= p
+			continue
+		}
+		if _, ok := permission.Lookup(name); !ok {
+			return Access{}, fmt.Errorf("unknown role %q", name)
+		}
+		a.Roles = append(a.Roles, name)
+	}
+	if a.Anonymous() && (a.Allow != permission.Anonymous || len(a.Roles) > 0) {
+		return Access{}, errors.New("anonymous cannot be combined with other roles")
+	}
+	return a, nil
+}
+
+// Policy holds all the access rules
+type Policy struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Parse parses the JSON policy checking that every rule is valid
+func Parse(b []byte) (*Policy, error) {
+	var p Policy
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, fmt.Errorf("rbac: cannot parse the policy: %w", err)
+	}
+	if _, err := p.Compile(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Load reads and parses the JSON policy found at path
+func Load(path string) (*Policy, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(b)
+}
+
+// Compile resolves all the rules keyed by the route they match
+func (p *Policy) Compile() (map[string]Access, error) {
+	compiled := make(map[string]Access, len(p.Rules))
+	for i, rule := range p.Rules {
+		if rule.Resource == "" || rule.Method == "" || rule.URL == "" {
+			return nil, fmt.Errorf("rbac: rule %d must have a resource, a method and an url", i)
+		}
+		if len(rule.Roles) == 0 {
+			return nil, fmt.Errorf("rbac: rule %d (%s) has no roles", i, rule.Key())
+		}
+		if _, ok := compiled[rule.Key()]; ok {
+			return nil, fmt.Errorf("rbac: rule %d (%s) is defined twice", i, rule.Key())
+		}
+		access, err := rule.Resolve()
+		if err != nil {
+			return nil, fmt.Errorf("rbac: rule %d (%s): %w", i, rule.Key(), err)
+		}
+		compiled[rule.Key()] = access
+	}
+	return compiled, nil
+}
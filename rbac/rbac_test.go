@@ -0,0 +1,65 @@
+package rbac_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/hoenirvili/rester/permission"
+	"github.com/hoenirvili/rester/rbac"
+	"github.com/stretchr/testify/require"
+)
+
+var support = permission.Register("support")
+
+func TestParse(t *testing.T) {
+	require := require.New(t)
+	p, err := rbac.Parse([]byte(`{"rules": [
+		{"resource": "/users", "method": "get", "url": "/", "roles": ["basic", "admin"]},
+		{"resource": "/users", "method": "DELETE", "url": "/{id}", "roles": ["admin", "support"], "require_all": true},
+		{"resource": "/health", "method": "GET", "url": "/", "roles": ["anonymous"]}
+	]}`))
+	require.NoError(err)
+	rules, err := p.Compile()
+	require.NoError(err)
+	require.Equal(map[string]rbac.Access{
+		"GET /users /":        {Allow: permission.Basic | permission.Admin},
+		"DELETE /users /{id}": {Allow: permission.Admin, Roles: []string{support.String()}, RequireAll: true},
+		"GET /health /":       {Allow: permission.Anonymous},
+	}, rules)
+	require.True(rules["GET /health /"].Anonymous())
+}
+
+func TestParseInvalid(t *testing.T) {
+	for name, policy := range map[string]string{
+		"json":      `{"rules": [`,
+		"fields":    `{"rules": [{"resource": "/users", "roles": ["admin"]}]}`,
+		"roles":     `{"rules": [{"resource": "/users", "method": "GET", "url": "/"}]}`,
+		"unknown":   `{"rules": [{"resource": "/users", "method": "GET", "url": "/", "roles": ["nobody"]}]}`,
+		"anonymous": `{"rules": [{"resource": "/users", "method": "GET", "url": "/", "roles": ["anonymous", "admin"]}]}`,
+		"twice": `{"rules": [
+			{"resource": "/users", "method": "GET", "url": "/", "roles": ["admin"]},
+			{"resource": "/users", "method": "get", "url": "/", "roles": ["basic"]}
+		]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := rbac.Parse([]byte(policy))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	require := require.New(t)
+	path := filepath.Join(t.TempDir(), "policy.json")
+	err := ioutil.WriteFile(path, []byte(`{"rules": [
+		{"resource": "/users", "method": "GET", "url": "/", "roles": ["basic"]}
+	]}`), 0o600)
+	require.NoError(err)
+	p, err := rbac.Load(path)
+	require.NoError(err)
+	require.Len(p.Rules, 1)
+
+	_, err = rbac.Load(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(err)
+}
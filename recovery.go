@@ -0,0 +1,91 @@
+package rester
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	chimiddleware "github.com/go-chi/chi/middleware"
+
+	"github.com/hoenirvili/rester/resource"
+	"github.com/hoenirvili/rester/response"
+)
+
+// RequestIDHeader is the header holding the correlation id of a request
+// If the client does not send one, a random id is generated
+const RequestIDHeader = "X-Request-Id"
+
+// Logger used for logging internal failures like recovered panics
+// The standard library *log.Logger satisfies this interface
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// WithRecovery recovers from every panic that occurs while serving
+// a request, including the ones from rendering the response,
+// logging the stack trace using the given logger and responding with
+// an internal server error that contains the request correlation id
+// If the logger is nil the standard library logger is used
+func WithRecovery(logger Logger) Option {
+	return func(opts *Options) {
+		if logger == nil {
+			logger = log.Default()
+		}
+		opts.recovery = logger
+	}
+}
+
+func correlationID(req *http.Request) string {
+	if id := req.Header.Get(RequestIDHeader); id != "" {
+		return id
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+func (r *Rester) recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// the wrapper keeps the http.Flusher, http.Hijacker
+		// and http.Pusher interfaces of the writer
+		ww := chimiddleware.NewWrapResponseWriter(w, req.ProtoMajor)
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			id := correlationID(req)
+			r.options.recovery.Printf("panic serving %s %s (correlation id: %s): %v\n%s",
+				req.Method, req.URL.Path, id, rec, debug.Stack())
+			if ww.Status() != 0 {
+				// the response was already started
+				return
+			}
+			w.Header().Set(RequestIDHeader, id)
+			r.internalError(id).Render(w)
+		}()
+		next.ServeHTTP(ww, req)
+	})
+}
+
+// internalError returns the response used when recovering from a panic
+func (r *Rester) internalError(id string) resource.Response {
+	const message = "internal server error"
+	if r.options.problems {
+		p := response.NewProblem(http.StatusInternalServerError, message)
+		p.Extensions = map[string]interface{}{"correlation_id": id}
+		return p
+	}
+	return &response.Response{
+		Error:      response.Error(message),
+		Details:    map[string]string{"correlation_id": id},
+		StatusCode: http.StatusInternalServerError,
+	}
+}
@@ -0,0 +1,138 @@
+package rester_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoenirvili/rester"
+	"github.com/hoenirvili/rester/permission"
+	"github.com/hoenirvili/rester/request"
+	"github.com/hoenirvili/rester/resource"
+	"github.com/hoenirvili/rester/response"
+	"github.com/hoenirvili/rester/route"
+)
+
+type panicPayload struct{}
+
+func (p panicPayload) Payload(permission.Permissions) (interface{}, error) {
+	panic("payload panic")
+}
+
+type panicResource struct{}
+
+func (p *panicResource) Routes() route.Routes {
+	return route.Routes{{
+		URL:    "/handler",
+		Method: resource.Get,
+		Handler: func(request.Request) resource.Response {
+			panic("handler panic")
+		},
+	}, {
+		URL:    "/payload",
+		Method: resource.Get,
+		Handler: func(request.Request) resource.Response {
+			return response.Payload(panicPayload{})
+		},
+	}}
+}
+
+func TestWithRecovery(t *testing.T) {
+	require := require.New(t)
+	logs := &bytes.Buffer{}
+	r := rester.New(rester.WithRecovery(log.New(logs, "", 0)))
+	r.Resource("/", new(panicResource))
+	require.NoError(r.Build())
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	for _, url := range []string{"/handler", "/payload"} {
+		req, err := http.NewRequest(http.MethodGet, server.URL+url, nil)
+		require.NoError(err)
+		req.Header.Set(rester.RequestIDHeader, "test-id")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(err)
+
+		body := map[string]interface{}{}
+		err = json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+		require.NoError(err)
+		require.Equal(http.StatusInternalServerError, resp.StatusCode)
+		require.Equal("test-id", resp.Header.Get(rester.RequestIDHeader))
+		require.Equal(map[string]interface{}{
+			"error":   "internal server error",
+			"details": map[string]interface{}{"correlation_id": "test-id"},
+		}, body)
+	}
+	require.Contains(logs.String(), "handler panic")
+	require.Contains(logs.String(), "payload panic")
+	require.Contains(logs.String(), "correlation id: test-id")
+}
+
+func TestWithRecoveryProblem(t *testing.T) {
+	require := require.New(t)
+	logs := &bytes.Buffer{}
+	r := rester.New(
+		rester.WithRecovery(log.New(logs, "", 0)),
+		rester.WithProblemDetails(),
+	)
+	r.Resource("/", new(panicResource))
+	require.NoError(r.Build())
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/handler")
+	require.NoError(err)
+	defer resp.Body.Close()
+
+	problem := map[string]interface{}{}
+	err = json.NewDecoder(resp.Body).Decode(&problem)
+	require.NoError(err)
+	id := resp.Header.Get(rester.RequestIDHeader)
+	require.Len(id, 32)
+	require.Equal(response.ProblemContentType, resp.Header.Get("Content-Type"))
+	require.Equal(id, problem["correlation_id"])
+	require.Equal(float64(http.StatusInternalServerError), problem["status"])
+}
+
+type streamResource struct {
+	flusher, hijacker bool
+}
+
+func (s *streamResource) Routes() route.Routes {
+	return route.Routes{{
+		URL:    "/stream",
+		Method: resource.Get,
+		Middlewares: []func(http.Handler) http.Handler{
+			func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					_, s.flusher = w.(http.Flusher)
+					_, s.hijacker = w.(http.Hijacker)
+					next.ServeHTTP(w, r)
+				})
+			},
+		},
+		Handler: index,
+	}}
+}
+
+func TestWithRecoveryKeepsWriterInterfaces(t *testing.T) {
+	require := require.New(t)
+	r := rester.New(rester.WithRecovery(log.New(&bytes.Buffer{}, "", 0)))
+	s := new(streamResource)
+	r.Resource("/", s)
+	require.NoError(r.Build())
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/stream")
+	require.NoError(err)
+	resp.Body.Close()
+	require.True(s.flusher)
+	require.True(s.hijacker)
+}
@@ -0,0 +1,142 @@
+package request
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/hoenirvili/rester/permission"
+)
+
+// Claims holds the claims of the token validated for the request
+type Claims struct {
+	// ID is the "jti" claim
+	ID string
+	// Subject is the "sub" claim
+	Subject string
+	// Issuer is the "iss" claim
+	Issuer string
+	// Audience is the "aud" claim
+	Audience []string
+	// ExpiresAt is the "exp" claim
+	ExpiresAt time.Time
+	// IssuedAt is the "iat" claim
+	IssuedAt time.Time
+	// NotBefore is the "nbf" claim
+	NotBefore time.Time
+	// Permissions is the permission resolved from the claims
+	Permissions permission.Permissions
+	// Roles holds the named roles resolved from the claims
+	Roles permission.Set
+	// Raw holds all the claims as returned by the token validator
+	Raw map[string]interface{}
+	// Custom holds a pointer to the custom claims struct if one
+	// was registered using rester.WithCustomClaims
+	Custom interface{}
+}
+
+// claimsKey is the context key of the token claims
+type claimsKey struct{}
+
+// NewClaims returns the claims holding the registered claims found in raw
+func NewClaims(raw map[string]interface{}) *Claims {
+	c := &Claims{Raw: raw}
+	c.ID, _ = raw["jti"].(string)
+	c.Subject, _ = raw["sub"].(string)
+	c.Issuer, _ = raw["iss"].(string)
+	switch aud := raw["aud"].(type) {
+	case string:
+		c.Audience = []string{aud}
+	case []string:
+		c.Audience = aud
+	case []interface{}:
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				c.Audience = append(c.Audience, s)
+			}
+		}
+	}
+	c.ExpiresAt = numericDate(raw["exp"])
+	c.IssuedAt = numericDate(raw["iat"])
+	c.NotBefore = numericDate(raw["nbf"])
+	return c
+}
+
+// numericDate converts the seconds since epoch into a time
+func numericDate(v interface{}) time.Time {
+	var secs float64
+	switch n := v.(type) {
+	case float64:
+		secs = n
+	case int64:
+		secs = float64(n)
+	case int:
+		secs = float64(n)
+	default:
+		return time.Time{}
+	}
+	return time.Unix(int64(secs), 0)
+}
+
+// WithClaims returns a shallow copy of r holding the token claims
+func WithClaims(r *http.Request, c *Claims) *http.Request {
+	ctx := context.WithValue(r.Context(), claimsKey{}, c)
+	return r.WithContext(ctx)
+}
+
+// Claims returns the claims of the request token
+// This is nil if the route is not secured
+func (r Request) Claims() *Claims {
+	c, _ := r.Context().Value(claimsKey{}).(*Claims)
+	return c
+}
+
+// Roles returns the named roles of the request token
+func (r Request) Roles() permission.Set {
+	c := r.Claims()
+	if c == nil {
+		return permission.Set{}
+	}
+	return c.Roles
+}
+
+// Claim returns the raw claim of the request token as a T
+// Numeric claims can be retrieved as any numeric type
+// If the claim is missing or it can't be stored into a T
+// the zero value of T and false are returned
+func Claim[T any](r Request, key string) (T, bool) {
+	var t T
+	c := r.Claims()
+	if c == nil {
+		return t, false
+	}
+	raw, ok := c.Raw[key]
+	if !ok {
+		return t, false
+	}
+	if v, ok := raw.(T); ok {
+		return v, true
+	}
+	src, dst := reflect.ValueOf(raw), reflect.ValueOf(&t).Elem()
+	if !src.IsValid() || !numeric(src.Kind()) || !numeric(dst.Kind()) {
+		return t, false
+	}
+	dst.Set(src.Convert(dst.Type()))
+	return t, true
+}
+
+// CustomClaims returns the custom claims struct of the request token
+// registered using rester.WithCustomClaims
+func CustomClaims[T any](r Request) (*T, bool) {
+	c := r.Claims()
+	if c == nil {
+		return nil, false
+	}
+	t, ok := c.Custom.(*T)
+	return t, ok
+}
+
+func numeric(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Float64
+}
@@ -0,0 +1,74 @@
+package request_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoenirvili/rester/request"
+)
+
+func TestNewClaims(t *testing.T) {
+	require := require.New(t)
+	c := request.NewClaims(map[string]interface{}{
+		"jti": "1",
+		"sub": "alice",
+		"iss": "auth",
+		"aud": "api",
+		"exp": float64(2000000000),
+		"iat": float64(1000000000),
+	})
+	require.Equal("1", c.ID)
+	require.Equal("alice", c.Subject)
+	require.Equal("auth", c.Issuer)
+	require.Equal([]string{"api"}, c.Audience)
+	require.Equal(time.Unix(2000000000, 0), c.ExpiresAt)
+	require.Equal(time.Unix(1000000000, 0), c.IssuedAt)
+	require.True(c.NotBefore.IsZero())
+
+	c = request.NewClaims(map[string]interface{}{"aud": []interface{}{"api", "web"}})
+	require.Equal([]string{"api", "web"}, c.Audience)
+}
+
+type custom struct {
+	Tenant string
+}
+
+func TestClaim(t *testing.T) {
+	require := require.New(t)
+	req := request.New(new(http.Request), nil)
+	require.Nil(req.Claims())
+	_, ok := request.Claim[string](req, "sub")
+	require.False(ok)
+	_, ok = request.CustomClaims[custom](req)
+	require.False(ok)
+
+	c := request.NewClaims(map[string]interface{}{
+		"sub":   "alice",
+		"seats": float64(3),
+		"admin": true,
+	})
+	c.Custom = &custom{"acme"}
+	req = request.New(request.WithClaims(new(http.Request), c), nil)
+	require.Equal(c, req.Claims())
+
+	sub, ok := request.Claim[string](req, "sub")
+	require.True(ok)
+	require.Equal("alice", sub)
+	seats, ok := request.Claim[int](req, "seats")
+	require.True(ok)
+	require.Equal(3, seats)
+	admin, ok := request.Claim[bool](req, "admin")
+	require.True(ok)
+	require.True(admin)
+	_, ok = request.Claim[int](req, "sub")
+	require.False(ok)
+	_, ok = request.Claim[string](req, "missing")
+	require.False(ok)
+
+	cc, ok := request.CustomClaims[custom](req)
+	require.True(ok)
+	require.Equal("acme", cc.Tenant)
+}
@@ -0,0 +1,24 @@
+package request
+
+import (
+	"context"
+	"net/http"
+)
+
+// tokenSourceKey is the context key of the token source
+type tokenSourceKey struct{}
+
+// WithTokenSource returns a shallow copy of r holding the source
+// of the request token, like "header:Authorization"
+func WithTokenSource(r *http.Request, source string) *http.Request {
+	ctx := context.WithValue(r.Context(), tokenSourceKey{}, source)
+	return r.WithContext(ctx)
+}
+
+// TokenSource returns the part of the request where the token was found
+// This is empty if the route is not secured or the token validator
+// does not report the source
+func (r Request) TokenSource() string {
+	source, _ := r.Context().Value(tokenSourceKey{}).(string)
+	return source
+}
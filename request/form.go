@@ -0,0 +1,130 @@
+package request
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// FormTag is the struct tag name used when decoding form bodies
+// If a field does not have this tag, the json name or the field name is used
+const FormTag = "form"
+
+// Form decodes the url encoded or multipart form body into the struct p
+func (r Request) Form(p interface{}) error {
+	ct := r.Header.Get("Content-Type")
+	switch {
+	case strings.Contains(ct, "multipart/form-data"):
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			return err
+		}
+	case strings.Contains(ct, "application/x-www-form-urlencoded"):
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+	default:
+		return errors.New("Invalid content type header, we only support form bodies")
+	}
+	return decodeForm(r.PostForm, p)
+}
+
+func decodeForm(form url.Values, p interface{}) error {
+	value := reflect.ValueOf(p)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return errors.New("cannot decode a form into a non pointer value")
+	}
+	value = value.Elem()
+	if value.Kind() != reflect.Struct {
+		return errors.New("cannot decode a form into a non struct value")
+	}
+
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		key := formKey(field)
+		if key == "-" {
+			continue
+		}
+		inputs, ok := form[key]
+		if !ok || len(inputs) == 0 {
+			continue
+		}
+		if err := setField(value.Field(i), inputs); err != nil {
+			return fmt.Errorf("cannot decode form key %q: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func formKey(field reflect.StructField) string {
+	if key := strings.Split(field.Tag.Get(FormTag), ",")[0]; key != "" {
+		return key
+	}
+	if key := strings.Split(field.Tag.Get("json"), ",")[0]; key != "" {
+		return key
+	}
+	return field.Name
+}
+
+func setField(field reflect.Value, inputs []string) error {
+	switch field.Kind() {
+	case reflect.Ptr:
+		v := reflect.New(field.Type().Elem())
+		if err := setField(v.Elem(), inputs); err != nil {
+			return err
+		}
+		field.Set(v)
+		return nil
+	case reflect.Slice:
+		slice := reflect.MakeSlice(field.Type(), len(inputs), len(inputs))
+		for i, input := range inputs {
+			if err := setScalar(slice.Index(i), input); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	default:
+		return setScalar(field, inputs[0])
+	}
+}
+
+func setScalar(field reflect.Value, input string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(input)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(input)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(input, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(input, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(input, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package request_test
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoenirvili/rester/request"
+)
+
+type testForm struct {
+	Name   string   `form:"name"`
+	Age    int      `json:"age"`
+	Score  *float64 `form:"score"`
+	Active bool
+	Tags   []string `form:"tags"`
+	Skip   string   `form:"-"`
+}
+
+func newFormRequest(body string) *http.Request {
+	req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestForm(t *testing.T) {
+	require := require.New(t)
+	values := url.Values{
+		"name":   {"test"},
+		"age":    {"20"},
+		"score":  {"9.5"},
+		"Active": {"true"},
+		"tags":   {"a", "b"},
+		"-":      {"skip"},
+	}
+	rr := request.New(newFormRequest(values.Encode()), nil)
+	f := testForm{}
+	err := rr.Form(&f)
+	require.NoError(err)
+	score := 9.5
+	require.Equal(testForm{
+		Name:   "test",
+		Age:    20,
+		Score:  &score,
+		Active: true,
+		Tags:   []string{"a", "b"},
+	}, f)
+}
+
+func TestFormWithErrors(t *testing.T) {
+	require := require.New(t)
+	rr := request.New(newFormRequest("age=test"), nil)
+	err := rr.Form(&testForm{})
+	require.Error(err)
+
+	rr = request.New(newFormRequest("age=20"), nil)
+	err = rr.Form(testForm{})
+	require.Error(err)
+
+	req := newFormRequest("")
+	req.Header.Set("Content-Type", "text/plain")
+	rr = request.New(req, nil)
+	err = rr.Form(&testForm{})
+	require.Error(err)
+}
@@ -10,12 +10,14 @@ import (
 
 	"github.com/hoenirvili/rester/permission"
 	"github.com/hoenirvili/rester/query"
+	"github.com/hoenirvili/rester/validate"
 	"github.com/hoenirvili/rester/value"
 )
 
 type Request struct {
 	*http.Request
 	pairs query.Pairs
+	bound interface{}
 }
 
 func (r Request) Pairs() query.Pairs {
@@ -26,24 +28,28 @@ func New(r *http.Request, pairs query.Pairs) Request {
 	if pairs == nil {
 		pairs = make(query.Pairs)
 	}
-	return Request{r, pairs}
+	return Request{Request: r, pairs: pairs}
 }
 
 func (r Request) Permission() permission.Permissions {
-	value := r.Context().Value("permissions")
-	if value == nil {
+	c := r.Claims()
+	if c == nil {
 		return permission.NoPermission
 	}
-	return value.(permission.Permissions)
+	return c.Permissions
 }
 
+// Query parses the query param value of the key
+// If the key is not present in the url, the declared default is returned
 func (r Request) Query(key string) value.Value {
-	input := ""
-	v, ok := r.URL.Query()[key]
-	if ok {
-		input = v[0]
+	inputs := r.pairs.Inputs(key, r.URL.Query())
+	if len(inputs) == 0 {
+		return r.pairs.ParseDefault(key)
 	}
-	return value.Parse(input, r.pairs[key].Type)
+	if r.pairs[key].List != query.Single {
+		return value.ParseList(inputs, r.pairs[key].Type)
+	}
+	return value.Parse(inputs[0], r.pairs[key].Type)
 }
 
 func (r Request) JSON(p interface{}) error {
@@ -54,6 +60,35 @@ func (r Request) JSON(p interface{}) error {
 	return json.NewDecoder(r.Request.Body).Decode(p)
 }
 
+// Bind decodes the json or form body into p and validates it
+// using the rules declared in the validate struct tags
+// If the body is valid but does not respect the rules a validate.Errors
+// is returned
+func (r Request) Bind(p interface{}) error {
+	var err error
+	if strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		err = r.JSON(p)
+	} else {
+		err = r.Form(p)
+	}
+	if err != nil {
+		return err
+	}
+	return validate.Struct(p)
+}
+
+// Bound returns the body bound and validated before calling the handler
+// This is nil if the route did not declare a body
+func (r Request) Bound() interface{} {
+	return r.bound
+}
+
+// WithBound returns a copy of the request holding the bound body p
+func (r Request) WithBound(p interface{}) Request {
+	r.bound = p
+	return r
+}
+
 func (r Request) URLParam(key string, t value.Type) value.Value {
 	input := chi.URLParam(r.Request, key)
 	return value.Parse(input, t)
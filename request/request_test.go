@@ -8,9 +8,12 @@ import (
 	"net/url"
 	"testing"
 
+	"github.com/go-chi/chi"
+
 	"github.com/hoenirvili/rester/permission"
 	"github.com/hoenirvili/rester/query"
 	"github.com/hoenirvili/rester/request"
+	"github.com/hoenirvili/rester/validate"
 	"github.com/hoenirvili/rester/value"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -42,12 +45,8 @@ func (r *requestSuite) TestPermission() {
 	rr := request.New(req, query.Pairs{})
 	require.Equal(rr.Permission(), permission.NoPermission)
 
-	ctx := context.WithValue(
-		context.Background(),
-		"permissions",
-		permission.Basic,
-	)
-	req = req.WithContext(ctx)
+	req = request.WithClaims(req.WithContext(context.Background()),
+		&request.Claims{Permissions: permission.Basic})
 	rr = request.New(req, nil)
 	require.Equal(rr.Permission(), permission.Basic)
 }
@@ -61,6 +60,51 @@ func (r *requestSuite) TestQuery() {
 	require.Equal(v.String(), "test")
 }
 
+func (r *requestSuite) TestQueryList() {
+	require := r.Require()
+	req := new(http.Request)
+	req.URL, _ = url.Parse(`www.test.com/base?ids=1,2,3&states[]=open&states[]=closed`)
+	rr := request.New(req, query.Pairs{
+		"ids":    query.Value{Type: value.Int64, List: query.Comma},
+		"states": query.Value{Type: value.String, List: query.Bracket},
+		"other":  query.Value{Type: value.Int, List: query.Repeated},
+	})
+	ids := rr.Query("ids")
+	require.NoError(ids.Error())
+	require.Equal([]int64{1, 2, 3}, ids.Int64s())
+	states := rr.Query("states")
+	require.NoError(states.Error())
+	require.Equal([]string{"open", "closed"}, states.Strings())
+	require.Error(rr.Query("other").Error())
+}
+
+func (r *requestSuite) TestQueryDefault() {
+	require := r.Require()
+	req := new(http.Request)
+	req.URL, _ = url.Parse(`www.test.com/base?limit=5`)
+	rr := request.New(req, query.Pairs{
+		"page":  query.Value{Type: value.Int, Default: "1"},
+		"limit": query.Value{Type: value.Int, Default: "10"},
+		"ids":   query.Value{Type: value.Int, List: query.Comma, Default: "1,2"},
+	})
+	require.Equal(1, rr.Query("page").Int())
+	require.Equal(5, rr.Query("limit").Int())
+	require.Equal([]int{1, 2}, rr.Query("ids").Ints())
+}
+
+func (r *requestSuite) TestURLParam() {
+	require := r.Require()
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "123e4567-e89b-12d3-a456-426614174000")
+	rctx.URLParams.Add("active", "true")
+	req := new(http.Request)
+	req = req.WithContext(context.WithValue(context.Background(), chi.RouteCtxKey, rctx))
+	rr := request.New(req, nil)
+	require.Equal("123e4567-e89b-12d3-a456-426614174000", rr.URLParam("id", value.UUID).UUID())
+	require.True(rr.URLParam("active", value.Bool).Bool())
+	require.Error(rr.URLParam("id", value.Int).Error())
+}
+
 type testJSON struct {
 	Test string `json:"test"`
 }
@@ -77,6 +121,41 @@ func (r *requestSuite) TestJSON() {
 	require.Equal(t.Test, "test")
 }
 
+type testBind struct {
+	Test string `json:"test" form:"test" validate:"required,min=3"`
+}
+
+func (r *requestSuite) TestBind() {
+	require := r.Require()
+	req := new(http.Request)
+	req.Body = ioutil.NopCloser(bytes.NewBufferString(`{"test":"test"}`))
+	req.Header = http.Header{"Content-Type": []string{"application/json"}}
+	rr := request.New(req, nil)
+	t := new(testBind)
+	err := rr.Bind(t)
+	require.NoError(err)
+	require.Equal(t.Test, "test")
+
+	req, _ = http.NewRequest(http.MethodPost, "/", bytes.NewBufferString("test=te"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr = request.New(req, nil)
+	err = rr.Bind(t)
+	require.Equal(validate.Errors{{
+		Field:   "test",
+		Rule:    "min",
+		Message: "length must be at least 3",
+	}}, err)
+}
+
+func (r *requestSuite) TestBound() {
+	require := r.Require()
+	rr := request.New(new(http.Request), nil)
+	require.Nil(rr.Bound())
+	t := &testBind{"test"}
+	rr = rr.WithBound(t)
+	require.Equal(t, rr.Bound())
+}
+
 func TestRequestSuite(t *testing.T) {
 	suite.Run(t, new(requestSuite))
 }
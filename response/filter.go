@@ -0,0 +1,483 @@
+package response
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hoenirvili/rester/permission"
+)
+
+// TagName is the struct tag used for restricting the payload fields
+// e.g. `rester:"perm=admin|super"` keeps the field only for tokens
+// that have the admin or the super permission
+// The names can be built-in permissions or registered roles,
+// unknown names never match so the field is always removed
+// and anonymous always matches so the field is seen by everyone
+const TagName = "rester"
+
+// guard holds who can see a field
+type guard struct {
+	allow permission.Permissions
+	roles []permission.Role
+}
+
+// parseGuard parses the value of the rester struct tag
+func parseGuard(tag string) guard {
+	var g guard
+	for _, option := range strings.Split(tag, ",") {
+		option = strings.TrimSpace(option)
+		if !strings.HasPrefix(option, "perm=") {
+			continue
+		}
+		for _, name := range strings.Split(strings.TrimPrefix(option, "perm="), "|") {
+			name = strings.TrimSpace(name)
+			if p, ok := permission.Named(name); ok {
+				g.allow |= p
+				continue
+			}
+			if r, ok := permission.Lookup(name); ok {
+				g.roles = append(g.roles, r)
+			}
+		}
+	}
+	return g
+}
+
+func (g guard) allows(p permission.Permissions, roles permission.Set) bool {
+	// anonymous requests carry no permission bit
+	if g.allow&permission.Anonymous != 0 || p&g.allow != 0 {
+		return true
+	}
+	for _, r := range g.roles {
+		if roles.Has(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// field describes a single json member of a struct
+type field struct {
+	name      string
+	index     []int
+	guards    []guard
+	omitEmpty bool
+	quoted    bool
+	tagged    bool
+	depth     int
+}
+
+var (
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	interfaceType     = reflect.TypeOf((*interface{})(nil)).Elem()
+
+	// fieldsCache holds the []field of every struct type
+	fieldsCache sync.Map
+	// guardedCache holds the classification of every type
+	guardedCache sync.Map
+)
+
+// marshaler reports if the type knows how to marshal itself
+func marshaler(t reflect.Type) bool {
+	return t.Implements(marshalerType) || t.Implements(textMarshalerType) ||
+		reflect.PtrTo(t).Implements(marshalerType) ||
+		reflect.PtrTo(t).Implements(textMarshalerType)
+}
+
+// fieldsOf returns the json members of the struct type following
+// the encoding/json rules for embedded structs and name conflicts
+func fieldsOf(t reflect.Type) []field {
+	if f, ok := fieldsCache.Load(t); ok {
+		return f.([]field)
+	}
+	var all []field
+	collect(t, nil, nil, 0, map[reflect.Type]bool{}, &all)
+
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].name != all[j].name {
+			return all[i].name < all[j].name
+		}
+		if all[i].depth != all[j].depth {
+			return all[i].depth < all[j].depth
+		}
+		return all[i].tagged && !all[j].tagged
+	})
+	fields := make([]field, 0, len(all))
+	for i := 0; i < len(all); {
+		j := i + 1
+		for j < len(all) && all[j].name == all[i].name {
+			j++
+		}
+		dominant := all[i]
+		conflict := j > i+1 && all[i+1].depth == dominant.depth &&
+			all[i+1].tagged == dominant.tagged
+		if !conflict {
+			fields = append(fields, dominant)
+		}
+		i = j
+	}
+	// keep the declaration order of the fields
+	sort.Slice(fields, func(i, j int) bool {
+		return lessIndex(fields[i].index, fields[j].index)
+	})
+	fieldsCache.Store(t, fields)
+	return fields
+}
+
+func lessIndex(a, b []int) bool {
+	for k := 0; k < len(a) && k < len(b); k++ {
+		if a[k] != b[k] {
+			return a[k] < b[k]
+		}
+	}
+	return len(a) < len(b)
+}
+
+func collect(t reflect.Type, index []int, guards []guard, depth int, visited map[reflect.Type]bool, out *[]field) {
+	if visited[t] {
+		return
+	}
+	visited[t] = true
+	defer delete(visited, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		path := append(append([]int(nil), index...), i)
+		fieldGuards := guards
+		if value, ok := sf.Tag.Lookup(TagName); ok {
+			fieldGuards = append(append([]guard(nil), guards...), parseGuard(value))
+		}
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			collect(ft, path, fieldGuards, depth+1, visited, out)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		f := field{
+			name:   name,
+			index:  path,
+			guards: fieldGuards,
+			tagged: name != "",
+			depth:  depth,
+		}
+		if f.name == "" {
+			f.name = sf.Name
+		}
+		for _, opt := range strings.Split(opts, ",") {
+			switch opt {
+			case "omitempty":
+				f.omitEmpty = true
+			case "string":
+				switch ft.Kind() {
+				case reflect.Bool, reflect.String,
+					reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+					reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+					reflect.Float32, reflect.Float64:
+					f.quoted = true
+				}
+			}
+		}
+		*out = append(*out, f)
+	}
+}
+
+// class describes if the values of a type must be filtered
+type class int
+
+const (
+	// unguarded types never contain guarded fields
+	unguarded class = iota
+	// dynamic types contain interfaces that can hold guarded values
+	dynamic
+	// guarded types always contain guarded fields
+	guarded
+)
+
+// classification is the cached class of a type
+type classification struct {
+	class class
+	err   error
+}
+
+// classOf returns the class of the type, it fails if the type
+// marshals itself but it has fields restricted by the rester tag
+func classOf(t reflect.Type) (class, error) {
+	if c, ok := guardedCache.Load(t); ok {
+		c := c.(classification)
+		return c.class, c.err
+	}
+	c, err := classify(t, map[reflect.Type]bool{}, false)
+	// only the root result is cached, the nested ones
+	// can be incomplete when the type is recursive
+	guardedCache.Store(t, classification{c, err})
+	return c, err
+}
+
+func classify(t reflect.Type, seen map[reflect.Type]bool, marshals bool) (class, error) {
+	if c, ok := guardedCache.Load(t); ok {
+		c := c.(classification)
+		return c.class, c.err
+	}
+	if seen[t] {
+		return unguarded, nil
+	}
+	if !marshals && marshaler(t) {
+		// the guarded fields can't be removed from the
+		// output of the marshaler so refuse to marshal it
+		c, err := classify(t, seen, true)
+		if err == nil && c == guarded {
+			err = fmt.Errorf("response: %s has fields restricted by the %q tag "+
+				"but it marshals itself", t, TagName)
+		}
+		return unguarded, err
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Interface:
+		return dynamic, nil
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return classify(t.Elem(), seen, false)
+	case reflect.Struct:
+		result := unguarded
+		for _, f := range fieldsOf(t) {
+			if len(f.guards) > 0 {
+				return guarded, nil
+			}
+			c, err := classify(t.FieldByIndex(f.index).Type, seen, false)
+			if err != nil || c == guarded {
+				return c, err
+			}
+			if c == dynamic {
+				result = dynamic
+			}
+		}
+		return result, nil
+	}
+	return unguarded, nil
+}
+
+// Filter returns the payload without the struct fields that can't be seen
+// using the given permissions and roles based on their rester struct tag
+// Nested structs, pointers, slices, arrays and maps are filtered recursively
+// Payloads that don't have any rester struct tag are returned as they are
+// Filter fails if a type that implements json.Marshaler or
+// encoding.TextMarshaler has fields restricted by the rester tag
+func Filter(payload interface{}, p permission.Permissions, roles permission.Set) (interface{}, error) {
+	f := filter{permission: p, roles: roles}
+	return f.value(reflect.ValueOf(payload))
+}
+
+type filter struct {
+	permission permission.Permissions
+	roles      permission.Set
+}
+
+// needs reports if the value holds guarded fields
+func needs(v reflect.Value) bool {
+	if !v.IsValid() {
+		return false
+	}
+	c, err := classOf(v.Type())
+	if err != nil || c == guarded {
+		return true
+	}
+	if c == unguarded {
+		return false
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return !v.IsNil() && needs(v.Elem())
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if needs(v.Index(i)) {
+				return true
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if needs(iter.Value()) {
+				return true
+			}
+		}
+	case reflect.Struct:
+		for _, field := range fieldsOf(v.Type()) {
+			if value, ok := fieldByIndex(v, field.index); ok && needs(value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (f filter) value(v reflect.Value) (interface{}, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+	// the values are rebuilt only if they hold guarded fields
+	if !needs(v) {
+		return v.Interface(), nil
+	}
+	if _, err := classOf(v.Type()); err != nil {
+		return nil, err
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return f.value(v.Elem())
+	case reflect.Slice, reflect.Array:
+		// keep nil slices null like encoding/json
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			value, err := f.value(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			list[i] = value
+		}
+		return list, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+		m := reflect.MakeMapWithSize(reflect.MapOf(v.Type().Key(), interfaceType), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			value, err := f.value(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			elem := reflect.Zero(interfaceType)
+			if value != nil {
+				elem = reflect.ValueOf(value)
+			}
+			m.SetMapIndex(iter.Key(), elem)
+		}
+		return m.Interface(), nil
+	case reflect.Struct:
+		return f.object(v)
+	}
+	return v.Interface(), nil
+}
+
+func (f filter) object(v reflect.Value) (object, error) {
+	fields := fieldsOf(v.Type())
+	o := make(object, 0, len(fields))
+	for _, field := range fields {
+		if !f.allows(field.guards) {
+			continue
+		}
+		value, ok := fieldByIndex(v, field.index)
+		if !ok || (field.omitEmpty && isEmptyValue(value)) {
+			continue
+		}
+		filtered, err := f.value(value)
+		if err != nil {
+			return nil, err
+		}
+		m := member{name: field.name, value: filtered}
+		if field.quoted && m.value != nil {
+			b, err := json.Marshal(m.value)
+			if err == nil {
+				m.value = string(b)
+			}
+		}
+		o = append(o, m)
+	}
+	return o, nil
+}
+
+func (f filter) allows(guards []guard) bool {
+	for _, g := range guards {
+		if !g.allows(f.permission, f.roles) {
+			return false
+		}
+	}
+	return true
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex but it
+// reports false if it passes through a nil embedded pointer
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// isEmptyValue reports if the value is empty using the encoding/json omitempty rules
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// object is a json object that keeps the order of it's members
+type object []member
+
+type member struct {
+	name  string
+	value interface{}
+}
+
+// MarshalJSON marshals the members in their order
+func (o object) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		name, err := json.Marshal(m.name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(name)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
@@ -0,0 +1,209 @@
+package response_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoenirvili/rester/permission"
+	"github.com/hoenirvili/rester/request"
+	"github.com/hoenirvili/rester/response"
+)
+
+var auditor = permission.Register("auditor")
+
+type address struct {
+	City   string `json:"city"`
+	Street string `json:"street" rester:"perm=admin"`
+}
+
+type Timestamps struct {
+	Created time.Time `json:"created"`
+	Deleted bool      `json:"deleted,omitempty" rester:"perm=super"`
+}
+
+type user struct {
+	Timestamps
+	ID       int               `json:"id,string"`
+	Name     string            `json:"name"`
+	Email    string            `json:"email,omitempty" rester:"perm=admin|auditor"`
+	Salary   *int              `json:"salary" rester:"perm=super"`
+	Address  address           `json:"address"`
+	Previous []address         `json:"previous"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Secret   string            `json:"-"`
+	internal string
+}
+
+func TestFilter(t *testing.T) {
+	salary := 10
+	created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	u := &user{
+		Timestamps: Timestamps{Created: created, Deleted: true},
+		ID:         1,
+		Name:       "alice",
+		Email:      "alice@example.com",
+		Salary:     &salary,
+		Address:    address{"Paris", "Rue de Rivoli"},
+		Previous:   []address{{"Lyon", "Rue Merciere"}},
+		Secret:     "secret",
+		internal:   "internal",
+	}
+	tests := map[string]struct {
+		permission permission.Permissions
+		roles      permission.Set
+		expected   string
+	}{
+		"none": {permission.NoPermission, permission.Set{}, `{
+			"created": "2020-01-01T00:00:00Z",
+			"id": "1",
+			"name": "alice",
+			"address": {"city": "Paris"},
+			"previous": [{"city": "Lyon"}]
+		}`},
+		"role": {permission.Basic, permission.NewSet(auditor), `{
+			"created": "2020-01-01T00:00:00Z",
+			"id": "1",
+			"name": "alice",
+			"email": "alice@example.com",
+			"address": {"city": "Paris"},
+			"previous": [{"city": "Lyon"}]
+		}`},
+		"all": {permission.Admin | permission.Super, permission.Set{}, `{
+			"created": "2020-01-01T00:00:00Z",
+			"deleted": true,
+			"id": "1",
+			"name": "alice",
+			"email": "alice@example.com",
+			"salary": 10,
+			"address": {"city": "Paris", "street": "Rue de Rivoli"},
+			"previous": [{"city": "Lyon", "street": "Rue Merciere"}]
+		}`},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			filtered, err := response.Filter(u, test.permission, test.roles)
+			require.NoError(t, err)
+			b, err := json.Marshal(filtered)
+			require.NoError(t, err)
+			require.JSONEq(t, test.expected, string(b))
+		})
+	}
+}
+
+func TestFilterUnguarded(t *testing.T) {
+	require := require.New(t)
+	for _, payload := range []interface{}{
+		map[string]int{"test": 1},
+		map[string]interface{}{"test": []interface{}{1, "test"}},
+		nil,
+	} {
+		filtered, err := response.Filter(payload, permission.NoPermission, permission.Set{})
+		require.NoError(err)
+		require.Equal(payload, filtered)
+	}
+
+	// guarded values held by interfaces are still filtered
+	filtered, err := response.Filter(map[string]interface{}{"address": address{"Paris", "Rue de Rivoli"}},
+		permission.NoPermission, permission.Set{})
+	require.NoError(err)
+	b, err := json.Marshal(filtered)
+	require.NoError(err)
+	require.JSONEq(`{"address":{"city":"Paris"}}`, string(b))
+}
+
+type marshalingUser struct {
+	Name  string `json:"name"`
+	Email string `json:"email" rester:"perm=admin"`
+}
+
+func (m marshalingUser) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"name": m.Name, "email": m.Email})
+}
+
+func TestFilterMarshaler(t *testing.T) {
+	require := require.New(t)
+	payload := []marshalingUser{{"alice", "alice@example.com"}}
+	_, err := response.Filter(payload, permission.NoPermission, permission.Set{})
+	require.Error(err)
+	_, err = response.Filter(map[string]interface{}{"user": payload[0]},
+		permission.NoPermission, permission.Set{})
+	require.Error(err)
+
+	w := httptest.NewRecorder()
+	response.Payload(payload).Render(w)
+	require.Equal(http.StatusInternalServerError, w.Code)
+	require.NotContains(w.Body.String(), "alice")
+}
+
+type catalog struct {
+	Items  []address          `json:"items"`
+	ByName map[string]address `json:"by_name"`
+	Motd   string             `json:"motd" rester:"perm=anonymous"`
+}
+
+func TestFilterLikeEncodingJSON(t *testing.T) {
+	require := require.New(t)
+	filtered, err := response.Filter(catalog{Motd: "hello"}, permission.NoPermission, permission.Set{})
+	require.NoError(err)
+	b, err := json.Marshal(filtered)
+	require.NoError(err)
+	// nil slices and maps stay null and anonymous fields are seen by everyone
+	require.JSONEq(`{"items":null,"by_name":null,"motd":"hello"}`, string(b))
+
+	filtered, err = response.Filter([]address(nil), permission.Admin, permission.Set{})
+	require.NoError(err)
+	b, err = json.Marshal(filtered)
+	require.NoError(err)
+	require.Equal("null", string(b))
+}
+
+func TestFilterKeepsOrder(t *testing.T) {
+	filtered, err := response.Filter([]address{{"Paris", "Rue de Rivoli"}},
+		permission.Admin, permission.Set{})
+	require.NoError(t, err)
+	b, err := json.Marshal(filtered)
+	require.NoError(t, err)
+	require.Equal(t, `[{"city":"Paris","street":"Rue de Rivoli"}]`, string(b))
+}
+
+func TestResponseServeHTTP(t *testing.T) {
+	require := require.New(t)
+	payload := &address{"Paris", "Rue de Rivoli"}
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	response.Payload(payload).ServeHTTP(w, req)
+	require.JSONEq(`{"city":"Paris"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	req = request.WithClaims(req.WithContext(context.Background()),
+		&request.Claims{Permissions: permission.Admin})
+	response.Payload(payload).ServeHTTP(w, req)
+	require.JSONEq(`{"city":"Paris","street":"Rue de Rivoli"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	response.Payload(payload).Render(w)
+	require.JSONEq(`{"city":"Paris"}`, w.Body.String())
+}
+
+func TestResponseServeHTTPReused(t *testing.T) {
+	require := require.New(t)
+	resp := response.Payload(&address{"Paris", "Rue de Rivoli"})
+
+	admin := request.WithClaims(httptest.NewRequest(http.MethodGet, "/", nil),
+		&request.Claims{Permissions: permission.Admin})
+	w := httptest.NewRecorder()
+	resp.ServeHTTP(w, admin)
+	require.JSONEq(`{"city":"Paris","street":"Rue de Rivoli"}`, w.Body.String())
+
+	// the permission of a previous request is never reused
+	w = httptest.NewRecorder()
+	resp.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.JSONEq(`{"city":"Paris"}`, w.Body.String())
+}
@@ -0,0 +1,80 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ProblemContentType is the media type used when rendering problem documents
+const ProblemContentType = "application/problem+json"
+
+// Problem defines a RFC 7807 problem details document used for
+// responding with machine readable http api errors
+type Problem struct {
+	// Type is a URI reference that identifies the problem type
+	// If this is empty "about:blank" is assumed
+	Type string
+	// Title is a short human-readable summary of the problem type
+	Title string
+	// Status is the http status code of the response
+	Status int
+	// Detail is a human-readable explanation specific to this
+	// occurrence of the problem
+	Detail string
+	// Instance is a URI reference that identifies the specific
+	// occurrence of the problem
+	Instance string
+	// Extensions holds additional members of the problem document
+	// Extensions can't override any of the members above
+	Extensions map[string]interface{}
+}
+
+// NewProblem returns a problem of the default "about:blank" type
+// using the http status text as it's title
+func NewProblem(status int, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// MarshalJSON marshals the problem and all it's extensions into a
+// single json object
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	members := make(map[string]interface{}, len(p.Extensions)+5)
+	for key, value := range p.Extensions {
+		members[key] = value
+	}
+	typ := p.Type
+	if typ == "" {
+		typ = "about:blank"
+	}
+	members["type"] = typ
+	set := func(key string, value interface{}, empty bool) {
+		if empty {
+			delete(members, key)
+			return
+		}
+		members[key] = value
+	}
+	set("title", p.Title, p.Title == "")
+	set("status", p.Status, p.Status == 0)
+	set("detail", p.Detail, p.Detail == "")
+	set("instance", p.Instance, p.Instance == "")
+	return json.Marshal(members)
+}
+
+// Render writes the problem document into the given http.ResponseWriter
+func (p *Problem) Render(w http.ResponseWriter) {
+	status := p.Status
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(p)
+}
+
+var _ json.Marshaler = (*Problem)(nil)
@@ -0,0 +1,63 @@
+package response_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoenirvili/rester/response"
+)
+
+func TestNewProblem(t *testing.T) {
+	p := response.NewProblem(http.StatusNotFound, "test")
+	require.Equal(t, &response.Problem{
+		Type:   "about:blank",
+		Title:  "Not Found",
+		Status: http.StatusNotFound,
+		Detail: "test",
+	}, p)
+}
+
+func TestProblemMarshalJSON(t *testing.T) {
+	require := require.New(t)
+	p := response.NewProblem(http.StatusBadRequest, `invalid "page"`)
+	p.Instance = "/test"
+	p.Extensions = map[string]interface{}{
+		"status": "override",
+		"code":   "invalid_param",
+	}
+	b, err := json.Marshal(p)
+	require.NoError(err)
+	require.JSONEq(`{
+		"type": "about:blank",
+		"title": "Bad Request",
+		"status": 400,
+		"detail": "invalid \"page\"",
+		"instance": "/test",
+		"code": "invalid_param"
+	}`, string(b))
+}
+
+func TestProblemMarshalJSONDefaultType(t *testing.T) {
+	b, err := json.Marshal(&response.Problem{})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type":"about:blank"}`, string(b))
+}
+
+func TestProblemRender(t *testing.T) {
+	require := require.New(t)
+	w := newResponseWriter()
+	response.NewProblem(http.StatusUnauthorized, "test").Render(w)
+	require.Equal(http.StatusUnauthorized, w.StatusCode())
+	require.Equal(http.Header{
+		"Content-Type": []string{response.ProblemContentType},
+	}, w.Header())
+	require.JSONEq(`{
+		"type": "about:blank",
+		"title": "Unauthorized",
+		"status": 401,
+		"detail": "test"
+	}`, string(w.Data()))
+}
@@ -9,6 +9,7 @@ import (
 	"reflect"
 
 	"github.com/hoenirvili/rester/permission"
+	"github.com/hoenirvili/rester/request"
 )
 
 // Error type used for defining json response errors
@@ -24,7 +25,9 @@ func (e Error) MarshalJSON() ([]byte, error) {
 		}
 	}
 
-	return []byte(`{"error":"` + str + `"}`), nil
+	return json.Marshal(struct {
+		Error string `json:"error"`
+	}{str})
 }
 
 // Response holds all response information
@@ -34,6 +37,10 @@ type Response struct {
 	// to the caller with an http json error
 	// like {"error": "message"}
 	Error Error
+	// Details holds additional information about the Error
+	// If set the error will be rendered as
+	// {"error": "message", "details": details}
+	Details interface{}
 	// Payload holds the raw ptr to a type that can be
 	// marshaled to json
 	// All the marshaling process is done with the standard
@@ -57,31 +64,57 @@ const emptyError Error = ""
 
 // Render writes the hole json response into the given http.ResponseWriter
 func (r *Response) Render(w http.ResponseWriter) {
+	r.render(w, r.permission, permission.Set{})
+}
+
+// render writes the response filtering the payload using the given
+// permission and roles, the response is never modified so the same
+// response can be rendered concurrently
+func (r *Response) render(w http.ResponseWriter, perm permission.Permissions, roles permission.Set) {
 	var (
 		payload interface{}
 		err     error
 	)
 
+	status := r.StatusCode
 	switch {
+	case r.Error != emptyError && r.Details != nil:
+		payload = struct {
+			Error   string      `json:"error"`
+			Details interface{} `json:"details"`
+		}{string(r.Error), r.Details}
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
 	case r.Error != emptyError:
 		payload = r.Error
-		if r.StatusCode == 0 {
-			r.StatusCode = http.StatusInternalServerError
+		if status == 0 {
+			status = http.StatusInternalServerError
 		}
 	default:
 		payload = r.Payload
 	}
 
-	if r.StatusCode == 0 {
-		r.StatusCode = http.StatusOK
+	if status == 0 {
+		status = http.StatusOK
 	}
 
+	if perm == 0 {
+		perm = permission.NoPermission
+	}
 	if p, ok := payload.(Payloader); ok {
-		payload, err = p.Payload(r.permission)
+		payload, err = p.Payload(perm)
 		if err != nil {
 			payload = Error(err.Error())
 		}
 	}
+	if r.Error == emptyError {
+		if payload, err = Filter(payload, perm, roles); err != nil {
+			// never send a payload that could not be filtered
+			payload = Error("cannot render the response payload")
+			status = http.StatusInternalServerError
+		}
+	}
 
 	header := w.Header()
 
@@ -91,7 +124,7 @@ func (r *Response) Render(w http.ResponseWriter) {
 				header.Set(key, value)
 			}
 		}
-		w.WriteHeader(r.StatusCode)
+		w.WriteHeader(status)
 		return
 	}
 
@@ -101,12 +134,24 @@ func (r *Response) Render(w http.ResponseWriter) {
 			header.Set(key, value)
 		}
 	}
-	w.WriteHeader(r.StatusCode)
+	w.WriteHeader(status)
 	// TODO(hoenir): if the response contains a payload that cannot be marshaled
 	// should we at least capture the error and return it to the client somehow?
 	json.NewEncoder(w).Encode(payload)
 }
 
+// ServeHTTP renders the response filtering the payload using
+// the permissions and roles of the request token
+// The permission given by WithPermission takes precedence
+func (r *Response) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	rr := request.New(req, nil)
+	perm := r.permission
+	if perm == 0 {
+		perm = rr.Permission()
+	}
+	r.render(w, perm, rr.Roles())
+}
+
 // Payloader defines a way to send back response payloads that
 // can be filtered using the default permission scheme
 type Payloader interface {
@@ -131,7 +176,6 @@ func Payload(payload interface{}) *Response {
 	return &Response{
 		StatusCode: http.StatusOK,
 		Payload:    payload,
-		permission: permission.NoPermission,
 	}
 }
 
@@ -142,7 +186,6 @@ func Created(payload interface{}) *Response {
 	return &Response{
 		StatusCode: http.StatusCreated,
 		Payload:    payload,
-		permission: permission.NoPermission,
 	}
 }
 
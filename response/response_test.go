@@ -19,6 +19,14 @@ func TestErrorMarshalJSON(t *testing.T) {
 	require.Equal(`{"error":"test string error"}`, string(b))
 }
 
+func TestErrorMarshalJSONQuotes(t *testing.T) {
+	require := require.New(t)
+	rerr := response.Error(`test "quoted" error`)
+	b, err := rerr.MarshalJSON()
+	require.NoError(err)
+	require.Equal(`{"error":"test \"quoted\" error"}`, string(b))
+}
+
 func TestErrorMarshalJSONErr(t *testing.T) {
 	require := require.New(t)
 	rerr := response.Error("")
@@ -165,6 +173,18 @@ func TestRender(t *testing.T) {
 	}
 }
 
+func TestRenderWithDetails(t *testing.T) {
+	r := &response.Response{
+		Error:      response.Error("test"),
+		Details:    []string{"first", "second"},
+		StatusCode: http.StatusBadRequest,
+	}
+	w := newResponseWriter()
+	r.Render(w)
+	require.Equal(t, http.StatusBadRequest, w.StatusCode())
+	require.Equal(t, `{"error":"test","details":["first","second"]}`+"\n", string(w.Data()))
+}
+
 func TestRenderContent(t *testing.T) {
 	r := response.NotFound("test")
 	w := newResponseWriter()
@@ -5,24 +5,33 @@
 package rester
 
 import (
-	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"os"
 	"path"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/cors"
 
+	"github.com/hoenirvili/rester/audit"
 	"github.com/hoenirvili/rester/handler"
+	"github.com/hoenirvili/rester/openapi"
 	"github.com/hoenirvili/rester/permission"
+	"github.com/hoenirvili/rester/policy"
 	"github.com/hoenirvili/rester/query"
+	"github.com/hoenirvili/rester/rbac"
 	"github.com/hoenirvili/rester/request"
 	"github.com/hoenirvili/rester/resource"
 	"github.com/hoenirvili/rester/response"
 	"github.com/hoenirvili/rester/route"
+	"github.com/hoenirvili/rester/validate"
 )
 
 type config struct {
@@ -33,6 +42,18 @@ type config struct {
 		validator middleware
 	}
 	resources map[string]Resource
+	// errs holds all configuration problems found before Build
+	errs []*ConfigError
+	// policy holds the current *policyState compiled from the rbac policy
+	policy atomic.Value
+	// openapi holds the *openapi.Document served by WithOpenAPI
+	openapi atomic.Value
+	// reload serializes the rbac policy reloads
+	reload sync.Mutex
+}
+
+func (c *config) error(err error) {
+	c.errs = append(c.errs, &ConfigError{Err: err})
 }
 
 func (c *config) setValidator(m middleware) {
@@ -48,6 +69,8 @@ type Rester struct {
 	root    chi.Router
 	options Options
 	config  config
+	// built is true after Build mounted the routes
+	built bool
 }
 
 type middleware func(http.Handler) http.Handler
@@ -57,6 +80,8 @@ type middleware func(http.Handler) http.Handler
 func New(opts ...Option) *Rester {
 	options := Options{
 		corsOptions: defaultCors,
+		mapper:      permission.Bitmask("permissions"),
+		roles:       defaultRoles,
 	}
 	for _, setter := range opts {
 		setter(&options)
@@ -79,33 +104,46 @@ func (r *Rester) appendTokenMiddleware() {
 
 	middleware := func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-			claims, err := r.options.validator.Verify(req)
+			var (
+				claims map[string]interface{}
+				source string
+				err    error
+			)
+			if v, ok := r.options.validator.(SourceValidator); ok {
+				claims, source, err = v.VerifySource(req)
+			} else {
+				claims, err = r.options.validator.Verify(req)
+			}
 			if err != nil {
-				resp := response.Unauthorized(err.Error())
-				resp.Render(w)
+				r.unauthorized(w, req, err.Error())
 				return
 			}
 
-			p, ok := claims["permissions"]
-			if !ok {
-				resp := response.Unauthorized("No 'permissions' key found in the token")
-				resp.Render(w)
+			p, err := r.options.mapper(claims)
+			if err != nil {
+				r.unauthorized(w, req, err.Error())
 				return
 			}
 
-			value, ok := p.(float64)
-			if !ok {
-				resp := response.Unauthorized("Invalid permission value")
-				resp.Render(w)
+			roles, err := r.options.roles(claims)
+			if err != nil {
+				r.unauthorized(w, req, err.Error())
 				return
 			}
 
-			claims["permissions"] = permission.Permissions(value)
-			ctx := req.Context()
-			for key, value := range claims {
-				ctx = context.WithValue(ctx, key, value)
+			c := request.NewClaims(claims)
+			c.Permissions = r.options.hierarchy.Resolve(p)
+			c.Roles = roles
+			if r.options.customClaims != nil {
+				if c.Custom, err = decodeClaims(claims, r.options.customClaims); err != nil {
+					r.unauthorized(w, req, "Invalid token claims: "+err.Error())
+					return
+				}
+			}
+			req = request.WithClaims(req, c)
+			if source != "" {
+				req = request.WithTokenSource(req, source)
 			}
-			req = req.WithContext(ctx)
 			next.ServeHTTP(w, req)
 		})
 	}
@@ -113,7 +151,56 @@ func (r *Rester) appendTokenMiddleware() {
 	r.config.setValidator(middleware)
 }
 
-func guard(in permission.Permissions, on permission.Permissions) bool {
+// unauthorized rejects the request token recording the decision
+func (r *Rester) unauthorized(w http.ResponseWriter, req *http.Request, message string) {
+	r.record(req, nil, audit.Deny, message)
+	r.failure(http.StatusUnauthorized, message).Render(w)
+}
+
+// failure returns the response used by all the built-in failures
+// based on the configured error format
+func (r *Rester) failure(status int, message string) resource.Response {
+	return r.failureDetails(status, message, nil)
+}
+
+// failureDetails is like failure but the response also contains
+// a list of details about the failure
+func (r *Rester) failureDetails(status int, message string, details interface{}) resource.Response {
+	if r.options.problems {
+		p := response.NewProblem(status, message)
+		if details != nil {
+			p.Extensions = map[string]interface{}{"errors": details}
+		}
+		return p
+	}
+	return &response.Response{
+		Error:      response.Error(message),
+		Details:    details,
+		StatusCode: status,
+	}
+}
+
+// denied returns the response used when a route policy denies the request
+// The code of the denial is a member of the problem document
+func (r *Rester) denied(d *policy.Denial) resource.Response {
+	if r.options.problems {
+		p := response.NewProblem(http.StatusForbidden, d.Reason)
+		p.Extensions = map[string]interface{}{"code": d.Code}
+		return p
+	}
+	return &response.Response{
+		Error:      response.Error(d.Reason),
+		Details:    map[string]string{"code": d.Code},
+		StatusCode: http.StatusForbidden,
+	}
+}
+
+// guard checks if in has any of the permissions of on
+// or all of them if all is true
+func guard(in permission.Permissions, on permission.Permissions, all bool) bool {
+	if all {
+		return in&on == on
+	}
 	return in&on != 0
 }
 
@@ -134,6 +221,36 @@ type Options struct {
 
 	// corsOptions holds a series of options for setting up cors
 	corsOptions cors.Options
+
+	// openapi holds the api info used when serving the OpenAPI document
+	// If this is nil the document will not be served
+	openapi *openapi.Info
+
+	// problems is true if all built-in failures should be rendered
+	// as RFC 7807 problem documents
+	problems bool
+
+	// recovery logs all recovered panics
+	// If this is nil, panics will not be recovered
+	recovery Logger
+
+	// customClaims is the type of the struct the token claims are decoded into
+	customClaims reflect.Type
+
+	// mapper resolves the permissions of the token claims
+	mapper permission.Mapper
+
+	// roles resolves the named roles of the token claims
+	roles permission.RoleMapper
+
+	// hierarchy resolves the permissions inherited by the token permissions
+	hierarchy permission.Hierarchy
+
+	// rbac holds the access rules applied over the routes
+	rbac *rbac.Policy
+
+	// audit receives every authorization decision
+	audit audit.Sink
 }
 
 // WithCustomCors set's a custom set of cors for the server
@@ -157,6 +274,69 @@ type TokenValidator interface {
 	Verify(r *http.Request) (map[string]interface{}, error)
 }
 
+// SourceValidator is a TokenValidator that also reports the part
+// of the request where the token was found, like "cookie:session"
+// The source can be retrieved in the handler using request.TokenSource
+type SourceValidator interface {
+	TokenValidator
+	// VerifySource is like Verify but it also returns the token source
+	VerifySource(r *http.Request) (map[string]interface{}, string, error)
+}
+
+// WithPermissionMapper sets how the permissions are resolved from the
+// token claims, like permission.Roles("roles", table) for identity providers
+// that emit role names, by default the numeric "permissions" claim is used
+func WithPermissionMapper(m permission.Mapper) Option {
+	return func(opts *Options) { opts.mapper = m }
+}
+
+// WithHierarchy sets the permission hierarchy used by the route guard
+// With permission.DefaultHierarchy an Admin token can access Basic routes
+// By default the token must carry every permission bit explicitly
+// The inherited permissions are also reported by request.Permission
+func WithHierarchy(h permission.Hierarchy) Option {
+	return func(opts *Options) { opts.hierarchy = h }
+}
+
+// WithRoleMapper sets how the named roles are resolved from the
+// token claims, the tokens are rejected if the mapper fails
+// By default the role names of the "roles" claim are used and a
+// "roles" claim of any other shape is ignored
+func WithRoleMapper(m permission.RoleMapper) Option {
+	return func(opts *Options) { opts.roles = m }
+}
+
+// defaultRoles resolves the role names of the "roles" claim, tokens whose
+// "roles" claim is not meant for the role registry have no roles
+func defaultRoles(claims map[string]interface{}) (permission.Set, error) {
+	roles, err := permission.RoleNames("roles")(claims)
+	if err != nil {
+		return permission.Set{}, nil
+	}
+	return roles, nil
+}
+
+// WithCustomClaims decodes the token claims into a new value of the type of v
+// for every secured request, like WithCustomClaims(myClaims{})
+// The value can be retrieved in the handler using request.CustomClaims
+func WithCustomClaims(v interface{}) Option {
+	return func(opts *Options) { opts.customClaims = reflect.TypeOf(v) }
+}
+
+// decodeClaims decodes the claims into a new value of type t
+// returning a pointer to it
+func decodeClaims(claims map[string]interface{}, t reflect.Type) (interface{}, error) {
+	b, err := json.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+	v := reflect.New(t).Interface()
+	if err := json.Unmarshal(b, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
 // WithTokenValidator sets the underlying token validation implementation
 // to use to validate and extract token meta-data information to authorize and
 // authenticate the
@@ -164,13 +344,34 @@ func WithTokenValidator(t TokenValidator) Option {
 	return func(opts *Options) { opts.validator = t }
 }
 
+// WithProblemDetails renders all built-in failures like unauthorized,
+// forbidden, bad request, not found or method not allowed responses
+// as RFC 7807 problem documents
+func WithProblemDetails() Option {
+	return func(opts *Options) { opts.problems = true }
+}
+
 // WithVersioning appends to the path route the prefix "/version/"
 func WithVersioning(version string) Option {
 	return func(opts *Options) { opts.version = "/" + version }
 }
 
+// OpenAPIPath is the route, relative to the api version, that serves
+// the OpenAPI document when the WithOpenAPI option is used
+const OpenAPIPath = "/openapi.json"
+
+// WithOpenAPI serves the OpenAPI document describing all resources
+// under the OpenAPIPath route, the document is regenerated by ReloadPolicy
+func WithOpenAPI(info openapi.Info) Option {
+	return func(opts *Options) { opts.openapi = &info }
+}
+
 // NotFound defines a handler to respond whenever a route could not be found
 func (r *Rester) NotFound(h handler.Handler) {
+	if h == nil {
+		r.config.error(errors.New("cannot use a nil not found handler"))
+		return
+	}
 	// append into middleware stack
 	r.config.notfound = httphandler(h, nil)
 }
@@ -184,6 +385,10 @@ func (r *Rester) UseGlobalMiddleware(m ...middleware) {
 // MethodNotAllowed defines a handler to respond whenever a method is
 // not allowed on a route
 func (r *Rester) MethodNotAllowed(h handler.Handler) {
+	if h == nil {
+		r.config.error(errors.New("cannot use a nil method not allowed handler"))
+		return
+	}
 	// append into middleware stack
 	r.config.methodnotallowed = httphandler(h, nil)
 }
@@ -199,28 +404,21 @@ type Resource interface {
 	Routes() route.Routes
 }
 
-// checkPermission checks if the value with the key permission exists and if
-// it passes the guard check
-func checkPermission(allow permission.Permissions, req request.Request) error {
-	in := req.Request.Context().Value("permissions").(permission.Permissions)
-	if !guard(in, allow) {
-		return errors.New("you don't have permission to access this resource")
-	}
-	return nil
-}
-
-func (r *Rester) validRoute(route route.Route) {
-	if route.Handler == nil {
-		panic("Cannot use a nil handler")
-	}
-	if route.URL == "" {
-		panic("Cannot use an empty URL route")
+// checkPermission returns a function that checks if the permissions of the
+// request, including the inherited ones, pass the guard check
+func (r *Rester) checkPermission(all bool) func(permission.Permissions, request.Request) error {
+	return func(allow permission.Permissions, req request.Request) error {
+		in := r.options.hierarchy.Resolve(req.Permission())
+		if !guard(in, allow, all) {
+			return errors.New("you don't have permission to access this resource")
+		}
+		return nil
 	}
 }
 
-func serveFiles(r chi.Router, path string, root http.FileSystem) {
+func serveFiles(r chi.Router, path string, root http.FileSystem) error {
 	if strings.ContainsAny(path, "{}*") {
-		panic("serving files does not permit URL parameters")
+		return errors.New("serving files does not permit URL parameters")
 	}
 	fs := http.StripPrefix(path, http.FileServer(root))
 	if path != "/" && path[len(path)-1] != '/' {
@@ -233,31 +431,37 @@ func serveFiles(r chi.Router, path string, root http.FileSystem) {
 			fs.ServeHTTP(w, r)
 		},
 	))
+	return nil
 }
 
 // Static serve static files from the location pointed by dir
 // This has limited support so don't expect much customization
 func (r *Rester) Static(dir string) {
 	r.root.Group(func(g chi.Router) {
-		serveFiles(g, "/", http.Dir(dir))
+		if err := serveFiles(g, "/", http.Dir(dir)); err != nil {
+			r.config.error(err)
+		}
 	})
 }
 
+// StaticSpa serve a single page application from the location pointed by dir
+// Every request for a file that does not exist will be answered with index.html
 func (r *Rester) StaticSpa(dir string) {
 	r.root.Group(func(g chi.Router) {
-		staticSpaFile(g, "/", dir)
+		if err := staticSpaFile(g, "/", dir); err != nil {
+			r.config.error(err)
+		}
 	})
-
 }
 
-func staticSpaFile(r chi.Router, public string, static string) {
+func staticSpaFile(r chi.Router, public string, static string) error {
 	if strings.ContainsAny(public, "{}*") {
-		panic("FileServer does not permit URL parameters.")
+		return errors.New("serving files does not permit URL parameters")
 	}
 
 	root, _ := filepath.Abs(static)
 	if _, err := os.Stat(root); os.IsNotExist(err) {
-		panic("Static Documents Directory Not Found")
+		return errors.New("static documents directory " + static + " not found")
 	}
 
 	fs := http.StripPrefix(public, http.FileServer(http.Dir(root)))
@@ -278,18 +482,40 @@ func staticSpaFile(r chi.Router, public string, static string) {
 		// if the requested resource was found, serve it
 		fs.ServeHTTP(w, r)
 	})
+	return nil
 }
 
 // Build builds the internal state of the router making it ready for
 // dispatching requests
-func (r *Rester) Build() {
+// If the configuration or any of the resources are not valid, Build
+// will not register anything and returns a BuildError containing
+// all the problems found
+func (r *Rester) Build() error {
+	if r.built {
+		return errors.New("rester: Build was already called")
+	}
+	if err := r.validate(); err != nil {
+		return err
+	}
+	r.built = true
+	if r.options.rbac != nil {
+		// the policy was already checked by validate
+		s, _ := r.compilePolicy(r.options.rbac)
+		r.config.policy.Store(s)
+	}
 	r.root.Group(func(g chi.Router) {
 		if r.options.version == "" {
 			r.options.version = "/"
 		}
 		g.Route(r.options.version, func(router chi.Router) {
+			if r.options.problems {
+				r.defaultProblemHandlers()
+			}
 			router.NotFound(r.config.notfound)
 			router.MethodNotAllowed(r.config.methodnotallowed)
+			if r.options.recovery != nil {
+				router.Use(r.recoverer)
+			}
 			for _, middleware := range r.config.middleware.global {
 				router.Use(middleware)
 			}
@@ -299,73 +525,231 @@ func (r *Rester) Build() {
 			for path, resource := range r.config.resources {
 				r.resource(router, path, resource)
 			}
+
+			if r.options.openapi != nil {
+				r.config.openapi.Store(r.OpenAPI())
+				router.Get(OpenAPIPath, httphandler(func(request.Request) resource.Response {
+					return response.Payload(r.config.openapi.Load())
+				}, nil))
+			}
 		})
 	})
+	return nil
+}
+
+// OpenAPI returns an OpenAPI 3 document describing all the
+// resources and their routes
+func (r *Rester) OpenAPI() *openapi.Document {
+	var info openapi.Info
+	if r.options.openapi != nil {
+		info = *r.options.openapi
+	}
+	doc := openapi.New(info)
+
+	bases := make([]string, 0, len(r.config.resources))
+	for base := range r.config.resources {
+		bases = append(bases, base)
+	}
+	sort.Strings(bases)
+
+	for _, base := range bases {
+		for _, route := range r.config.resources[base].Routes() {
+			route = normalize(r.override(rbac.Key(base, route.Method, route.URL), route))
+			secured := r.options.validator != nil &&
+				route.Allow != permission.Anonymous
+			doc.Add(joinURL(r.options.version, base, route.URL), route, secured)
+		}
+	}
+	return doc
+}
+
+// joinURL joins all url parts into a single url
+// the same way the chi router composes mounted routes
+func joinURL(parts ...string) string {
+	url := ""
+	for _, part := range parts {
+		part = strings.Trim(part, "/")
+		if part == "" {
+			continue
+		}
+		url += "/" + part
+	}
+	if url == "" {
+		return "/"
+	}
+	return url
+}
+
+// defaultProblemHandlers sets the not found and method not allowed
+// handlers to respond with problem documents if they were not set
+func (r *Rester) defaultProblemHandlers() {
+	if r.config.notfound == nil {
+		r.config.notfound = httphandler(func(req request.Request) resource.Response {
+			p := response.NewProblem(http.StatusNotFound, "the requested route could not be found")
+			p.Instance = req.URL.Path
+			return p
+		}, nil)
+	}
+	if r.config.methodnotallowed == nil {
+		r.config.methodnotallowed = httphandler(func(req request.Request) resource.Response {
+			p := response.NewProblem(http.StatusMethodNotAllowed, "the method is not allowed on the requested route")
+			p.Instance = req.URL.Path
+			return p
+		}, nil)
+	}
 }
 
 func allowAllRequests(permission.Permissions, request.Request) error { return nil }
 
-func (r *Rester) decideWhichPermissionFunction(p permission.Permissions) func(permission.Permissions, request.Request) error {
+func (r *Rester) decideWhichPermissionFunction(route route.Route) func(permission.Permissions, request.Request) error {
 	fn := allowAllRequests
-	if p == permission.Anonymous {
+	// routes that require only roles have no permission to check
+	if route.Allow == permission.Anonymous || route.Allow == 0 {
 		return fn
 	}
 	// if we did specify a token validation schema, proceed with checking
 	// the permission return by the validation process in the context
 	if r.options.validator != nil {
-		fn = checkPermission
+		fn = r.checkPermission(route.RequireAll)
 	}
 	return fn
 }
 
 type makeHandlerConfig struct {
+	// access returns the checks of the route
+	access  func() access
+	route   route.Route
+	failure func(status int, message string, details interface{}) resource.Response
+	// denied returns the response used when the route policy denies the request
+	denied func(d *policy.Denial) resource.Response
+	// record sends the authorization decision to the audit sink
+	record func(req request.Request, d audit.Decision, reason string)
+}
+
+// access holds the permission and role checks of a route
+type access struct {
+	allow            permission.Permissions
 	isRequestAllowed func(permission.Permissions, request.Request) error
-	route            route.Route
+	// roles holds the roles of the route, the request must have one of them
+	roles      permission.Set
+	requireAll bool
+	anonymous  bool
+}
+
+// accessOf returns the checks of the normalized route
+func (r *Rester) accessOf(route route.Route) access {
+	a := access{
+		allow:            route.Allow,
+		isRequestAllowed: r.decideWhichPermissionFunction(route),
+		requireAll:       route.RequireAll,
+		anonymous:        route.Allow == permission.Anonymous,
+	}
+	if r.options.validator != nil && len(route.Roles) > 0 {
+		// the role names are checked by Build
+		a.roles, _ = permission.ParseSet(route.Roles...)
+	}
+	return a
 }
 
 func makeHandler(c makeHandlerConfig) handler.Handler {
 	return handler.Handler(func(req request.Request) resource.Response {
-		if err := c.isRequestAllowed(c.route.Allow, req); err != nil {
-			return response.Forbidden(err.Error())
+		a := c.access()
+		if err := a.isRequestAllowed(a.allow, req); err != nil {
+			c.record(req, audit.Deny, err.Error())
+			return c.failure(http.StatusForbidden, err.Error(), nil)
 		}
-		values := req.URL.Query()
-		pairs := req.Pairs()
-		for key := range pairs {
-			if pairs[key].Required {
-				if err := pairs.Parse(key, values); err != nil {
-					return response.BadRequest(err.Error())
+		if !a.roles.Empty() && !hasRoles(req.Roles(), a.roles, a.requireAll) {
+			message := "you don't have the role to access this resource"
+			c.record(req, audit.Deny, message)
+			return c.failure(http.StatusForbidden, message, nil)
+		}
+		if c.route.Policy != nil {
+			if err := c.route.Policy.Evaluate(req); err != nil {
+				d := policy.DenialOf(err)
+				c.record(req, audit.Deny, d.Reason)
+				return c.denied(d)
+			}
+		}
+		c.record(req, audit.Allow, "")
+		if err := req.Pairs().Validate(req.URL.Query()); err != nil {
+			return c.failure(http.StatusBadRequest, "invalid query parameters", err)
+		}
+		if c.route.Body != nil {
+			body := reflect.New(reflect.TypeOf(c.route.Body)).Interface()
+			if err := req.Bind(body); err != nil {
+				if errs, ok := err.(validate.Errors); ok {
+					return c.failure(http.StatusUnprocessableEntity, "invalid request body", errs)
 				}
+				return c.failure(http.StatusBadRequest, err.Error(), nil)
 			}
+			req = req.WithBound(body)
 		}
 		return c.route.Handler(req)
 	})
 }
 
+// hasRoles checks if in has any of the roles of on
+// or all of them if all is true
+func hasRoles(in, on permission.Set, all bool) bool {
+	if all {
+		return in.All(on)
+	}
+	return in.Any(on)
+}
+
+// normalize sets the default permission of the route
+// Routes that don't require any permission or role are anonymous
+func normalize(route route.Route) route.Route {
+	if route.Allow == 0 && len(route.Roles) == 0 {
+		route.Allow = permission.Anonymous
+	}
+	return route
+}
+
 func (r *Rester) resource(g chi.Router, base string, res Resource) {
 	g.Route(base, func(router chi.Router) {
 		routes := res.Routes()
 		for _, route := range routes {
-			r.validRoute(route)
-			if route.Allow == 0 {
-				route.Allow = permission.Anonymous
+			key := rbac.Key(base, route.Method, route.URL)
+			route = normalize(r.override(key, route))
+			static := r.accessOf(route)
+			config := makeHandlerConfig{
+				access:  func() access { return static },
+				route:   route,
+				failure: r.failureDetails,
+				denied:  r.denied,
+			}
+			if r.options.rbac != nil {
+				config.access = func() access { return r.currentAccess(key) }
+			}
+			t := &target{
+				pattern: joinURL(r.options.version, base, route.URL),
+				access:  config.access,
 			}
-			h := makeHandler(makeHandlerConfig{
-				isRequestAllowed: r.decideWhichPermissionFunction(route.Allow),
-				route:            route,
-			})
-			r.method(router, route, h)
+			config.record = func(req request.Request, d audit.Decision, reason string) {
+				// only the secured routes take authorization decisions
+				if r.options.validator != nil && !t.access().anonymous {
+					r.record(req.Request, t, d, reason)
+				}
+			}
+			h := makeHandler(config)
+			r.method(router, route, h, t)
 		}
 	})
 }
 
-func (r *Rester) method(router chi.Router, route route.Route, h handler.Handler) {
+func (r *Rester) method(router chi.Router, route route.Route, h handler.Handler, t *target) {
 	switch route.Allow {
 	case permission.Anonymous:
 		router.With(route.Middlewares...).MethodFunc(route.Method, route.URL, httphandler(h, route.QueryPairs))
 		return
 	default:
 		if r.options.validator != nil {
-			router.With(r.config.middleware.validator).
+			secured := router.With(r.config.middleware.validator)
+			if r.options.audit != nil {
+				secured = router.With(withTarget(t), r.config.middleware.validator)
+			}
+			secured.
 				With(route.Middlewares...).
 				MethodFunc(route.Method, route.URL, httphandler(h, route.QueryPairs))
 			return
@@ -375,9 +759,14 @@ func (r *Rester) method(router chi.Router, route route.Route, h handler.Handler)
 }
 
 // Resource initializes a resource with the all available sub-routes of the resource
+// Appending the same base twice is reported by Build
 func (r *Rester) Resource(base string, resource Resource) {
 	if _, ok := r.config.resources[base]; ok {
-		panic("cannot append the same resource " + base + "twice")
+		r.config.errs = append(r.config.errs, &ConfigError{
+			Resource: base,
+			Err:      errors.New("cannot append the same resource twice"),
+		})
+		return
 	}
 	r.config.resources[base] = resource
 }
@@ -389,6 +778,12 @@ func httphandler(h handler.Handler, pairs query.Pairs) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		req := request.New(r, pairs)
 		response := h(req)
+		// responses that are http.Handlers can use the request
+		// token for filtering their payload
+		if handler, ok := response.(http.Handler); ok {
+			handler.ServeHTTP(w, r)
+			return
+		}
 		response.Render(w)
 	})
 }
@@ -3,19 +3,29 @@ package rester_test
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/hoenirvili/rester"
+	"github.com/hoenirvili/rester/audit"
 	"github.com/hoenirvili/rester/handler"
+	"github.com/hoenirvili/rester/openapi"
+	"github.com/hoenirvili/rester/permission"
+	"github.com/hoenirvili/rester/policy"
+	"github.com/hoenirvili/rester/query"
+	"github.com/hoenirvili/rester/rbac"
 	"github.com/hoenirvili/rester/request"
 	"github.com/hoenirvili/rester/resource"
 	"github.com/hoenirvili/rester/response"
 	"github.com/hoenirvili/rester/route"
+	"github.com/hoenirvili/rester/value"
 )
 
 func TestNew(t *testing.T) {
@@ -95,7 +105,7 @@ func (r *resterSuite) SetupSuite() {
 	r.rester.NotFound(handler.Handler(notfound))
 	r.rester.MethodNotAllowed(handler.Handler(methodnotallowed))
 	r.rester.Resource("/", new(testResource))
-	r.rester.Build()
+	r.require.NoError(r.rester.Build())
 }
 
 func (r resterSuite) TestNotFound() {
@@ -138,7 +148,7 @@ func TestWithVersioning(t *testing.T) {
 	require := require.New(t)
 	rester := rester.New(rester.WithVersioning("v1"))
 	rester.Resource("/", new(testResource))
-	rester.Build()
+	require.NoError(rester.Build())
 	server := httptest.NewServer(rester)
 	defer server.Close()
 
@@ -157,3 +167,818 @@ func TestWithVersioning(t *testing.T) {
 func TestResterSuite(t *testing.T) {
 	suite.Run(t, new(resterSuite))
 }
+
+func TestWithOpenAPI(t *testing.T) {
+	require := require.New(t)
+	r := rester.New(
+		rester.WithVersioning("v1"),
+		rester.WithOpenAPI(openapi.Info{Title: "test", Version: "1.0.0"}),
+	)
+	r.Resource("/", new(testResource))
+	require.NoError(r.Build())
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/v1" + rester.OpenAPIPath)
+	require.NoError(err)
+	defer resp.Body.Close()
+	require.Equal(http.StatusOK, resp.StatusCode)
+
+	doc := openapi.Document{}
+	err = json.NewDecoder(resp.Body).Decode(&doc)
+	require.NoError(err)
+	require.Equal(openapi.Version, doc.OpenAPI)
+	require.Equal("test", doc.Info.Title)
+	require.Contains(doc.Paths, "/v1/test")
+	require.Contains(doc.Paths["/v1/test"], "post")
+}
+
+type openAPIResource struct{}
+
+func (o *openAPIResource) Routes() route.Routes {
+	return route.Routes{{
+		URL:     "/openapi.json",
+		Method:  resource.Get,
+		Handler: index,
+	}, {
+		URL:     "/openapi.json",
+		Method:  resource.Post,
+		Handler: index,
+	}}
+}
+
+func TestWithOpenAPICollision(t *testing.T) {
+	require := require.New(t)
+	r := rester.New(rester.WithOpenAPI(openapi.Info{Title: "test", Version: "1.0.0"}))
+	r.Resource("/", new(openAPIResource))
+	r.Resource("/openapi.json", new(testResource))
+	err := r.Build()
+	require.Error(err)
+	berr, ok := err.(rester.BuildError)
+	require.True(ok)
+	require.Len(berr, 2)
+	require.Contains(err.Error(), "resource /, GET /openapi.json: the route collides with the OpenAPI document route")
+	require.Contains(err.Error(), "resource /openapi.json: the resource base collides with the OpenAPI document route")
+
+	// without the document the routes can be used
+	r = rester.New()
+	r.Resource("/", new(openAPIResource))
+	require.NoError(r.Build())
+}
+
+type badResource struct{}
+
+func (b *badResource) Routes() route.Routes {
+	return route.Routes{{
+		URL:    "/test",
+		Method: "GETS",
+		Allow:  permission.Permissions(1 << 10),
+		QueryPairs: query.Pairs{
+			"ids":   query.Value{List: query.Bracket},
+			"ids[]": query.Value{},
+			"page":  query.Value{},
+			"Page":  query.Value{},
+		},
+	}, {
+		Method:  resource.Get,
+		Handler: index,
+	}}
+}
+
+func TestBuildWithErrors(t *testing.T) {
+	require := require.New(t)
+	r := rester.New()
+	r.Resource("/", new(testResource))
+	r.Resource("/", new(testResource))
+	r.Resource("/bad", new(badResource))
+	r.Resource("/test", new(otherResource))
+	r.NotFound(nil)
+	err := r.Build()
+	require.Error(err)
+
+	berr, ok := err.(rester.BuildError)
+	require.True(ok)
+	require.Len(berr, 8)
+	require.Contains(err.Error(), "rester: 8 configuration error(s)")
+	require.Contains(err.Error(), "cannot use a nil not found handler")
+	require.Contains(err.Error(), "resource /: cannot append the same resource twice")
+	require.Contains(err.Error(), "resource /bad, GETS /test: cannot use a nil handler")
+	require.Contains(err.Error(), `unknown http method "GETS"`)
+	require.Contains(err.Error(), "invalid permission value 1024")
+	require.Contains(err.Error(), `query keys "ids" and "ids[]" collide`)
+	require.NotContains(err.Error(), `"page"`)
+	require.Contains(err.Error(), "resource /bad, GET: cannot use an empty URL route")
+	require.Contains(err.Error(), "resource /test, POST /: route already defined by resource /")
+}
+
+type relativeResource struct{}
+
+func (r *relativeResource) Routes() route.Routes {
+	return route.Routes{{
+		URL:     "test",
+		Method:  resource.Get,
+		Handler: index,
+	}}
+}
+
+func TestBuildWithRelativeURLs(t *testing.T) {
+	require := require.New(t)
+	r := rester.New()
+	r.Resource("/", new(relativeResource))
+	r.Resource("users", new(testResource))
+	err := r.Build()
+	require.Error(err)
+	require.Contains(err.Error(), "resource /, GET test: the URL route must begin with '/'")
+	require.Contains(err.Error(), "resource users: the resource base must begin with '/'")
+}
+
+type invalidBody struct {
+	Code string `json:"code" validate:"regexp=[0-9"`
+}
+
+type invalidBodyResource struct{}
+
+func (i *invalidBodyResource) Routes() route.Routes {
+	return route.Routes{{
+		URL:     "/",
+		Method:  resource.Post,
+		Body:    invalidBody{},
+		Handler: index,
+	}}
+}
+
+func TestBuildWithInvalidBodyRules(t *testing.T) {
+	require := require.New(t)
+	r := rester.New()
+	r.Resource("/", new(invalidBodyResource))
+	err := r.Build()
+	require.Error(err)
+	require.Contains(err.Error(), `resource /, POST /: validate: invalid rule "regexp" on field code`)
+}
+
+func TestBuildTwice(t *testing.T) {
+	require := require.New(t)
+	r := rester.New()
+	r.Resource("/", new(testResource))
+	require.NoError(r.Build())
+	require.Error(r.Build())
+}
+
+type otherResource struct{}
+
+func (o *otherResource) Routes() route.Routes {
+	return route.Routes{{
+		URL:     "/",
+		Method:  resource.Post,
+		Handler: index,
+	}}
+}
+
+func TestWithProblemDetails(t *testing.T) {
+	require := require.New(t)
+	v := &validator{errVerify: errors.New("invalid token")}
+	r := rester.New(rester.WithProblemDetails(), rester.WithTokenValidator(v))
+	r.Resource("/", new(adminResource))
+	require.NoError(r.Build())
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	tests := []struct {
+		method, url string
+		status      int
+		detail      string
+	}{
+		{http.MethodGet, "/notfound", http.StatusNotFound, "the requested route could not be found"},
+		{http.MethodPost, "/admin", http.StatusMethodNotAllowed, "the method is not allowed on the requested route"},
+		{http.MethodGet, "/admin", http.StatusUnauthorized, "invalid token"},
+	}
+	for _, test := range tests {
+		req, err := http.NewRequest(test.method, server.URL+test.url, nil)
+		require.NoError(err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(err)
+
+		problem := map[string]interface{}{}
+		err = json.NewDecoder(resp.Body).Decode(&problem)
+		resp.Body.Close()
+		require.NoError(err)
+		require.Equal(test.status, resp.StatusCode)
+		require.Equal(response.ProblemContentType, resp.Header.Get("Content-Type"))
+		require.Equal(test.detail, problem["detail"])
+		require.Equal(float64(test.status), problem["status"])
+	}
+}
+
+type adminResource struct{}
+
+func (a *adminResource) Routes() route.Routes {
+	return route.Routes{{
+		URL:     "/admin",
+		Method:  resource.Get,
+		Allow:   permission.Admin,
+		Handler: index,
+	}}
+}
+
+type user struct {
+	Name string `json:"name" validate:"required,min=3"`
+}
+
+type userResource struct{}
+
+func (u *userResource) create(req request.Request) resource.Response {
+	return response.Created(req.Bound())
+}
+
+func (u *userResource) Routes() route.Routes {
+	return route.Routes{{
+		URL:     "/",
+		Method:  resource.Post,
+		Handler: u.create,
+		Body:    user{},
+	}}
+}
+
+func TestRouteBody(t *testing.T) {
+	require := require.New(t)
+	r := rester.New()
+	r.Resource("/users", new(userResource))
+	require.NoError(r.Build())
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	tests := []struct {
+		body   string
+		status int
+		expect string
+	}{
+		{`{"name":"test"}`, http.StatusCreated, `{"name":"test"}`},
+		{`{"name":"te"}`, http.StatusUnprocessableEntity, `{
+			"error": "invalid request body",
+			"details": [{"field":"name","rule":"min","message":"length must be at least 3"}]
+		}`},
+		{`{"name":`, http.StatusBadRequest, `{"error":"unexpected EOF"}`},
+	}
+	for _, test := range tests {
+		resp, err := http.Post(server.URL+"/users", "application/json",
+			bytes.NewBufferString(test.body))
+		require.NoError(err)
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(err)
+		require.Equal(test.status, resp.StatusCode)
+		require.JSONEq(test.expect, string(body))
+	}
+}
+
+type listResource struct{}
+
+func (l *listResource) Routes() route.Routes {
+	return route.Routes{{
+		URL:     "/",
+		Method:  resource.Get,
+		Handler: index,
+		QueryPairs: query.Pairs{
+			"ids":   query.Value{List: query.Bracket, MinItems: 3, MaxItems: 2},
+			"ids[]": query.Value{},
+			"page":  query.Value{MaxItems: 2},
+			"limit": query.Value{Type: value.Int, Default: "ten"},
+			"sort":  query.Value{Required: true, Default: "asc"},
+		},
+	}}
+}
+
+func TestBuildWithInvalidQuery(t *testing.T) {
+	require := require.New(t)
+	r := rester.New()
+	r.Resource("/", new(listResource))
+	err := r.Build()
+	require.Error(err)
+	require.Len(err, 5)
+	require.Contains(err.Error(), `query key "ids" has invalid item counts`)
+	require.Contains(err.Error(), `invalid default for query key "limit"`)
+	require.Contains(err.Error(), `required query key "sort" cannot have a default`)
+	require.Contains(err.Error(), `query keys "ids" and "ids[]" collide`)
+	require.Contains(err.Error(), `query key "page" declares item counts but it's not a list`)
+}
+
+type pageResource struct{}
+
+func (p *pageResource) index(req request.Request) resource.Response {
+	return response.Payload(req.Query("page").Int())
+}
+
+func (p *pageResource) Routes() route.Routes {
+	return route.Routes{{
+		URL:     "/",
+		Method:  resource.Get,
+		Handler: p.index,
+		QueryPairs: query.Pairs{
+			"page":  query.Value{Type: value.Int, Default: "1"},
+			"limit": query.Value{Type: value.Int},
+			"from":  query.Value{Type: value.Date, Required: true},
+		},
+	}}
+}
+
+func TestQueryValidation(t *testing.T) {
+	require := require.New(t)
+	r := rester.New()
+	r.Resource("/pages", new(pageResource))
+	require.NoError(r.Build())
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	tests := []struct {
+		query  string
+		status int
+		expect string
+	}{
+		{"?from=2020-01-01", http.StatusOK, "1"},
+		{"?from=2020-01-01&page=3", http.StatusOK, "3"},
+		{"?page=a&limit=b", http.StatusBadRequest, `{
+			"error": "invalid query parameters",
+			"details": [
+				{"param":"from","message":"the query param is required"},
+				{"param":"limit","message":"cannot parse the given input \"b\" into Int"},
+				{"param":"page","message":"cannot parse the given input \"a\" into Int"}
+			]
+		}`},
+	}
+	for _, test := range tests {
+		resp, err := http.Get(server.URL + "/pages" + test.query)
+		require.NoError(err)
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(err)
+		require.Equal(test.status, resp.StatusCode)
+		require.JSONEq(test.expect, string(body))
+	}
+}
+
+type claimsValidator struct {
+	claims map[string]interface{}
+}
+
+func (v *claimsValidator) Verify(r *http.Request) (map[string]interface{}, error) {
+	claims := make(map[string]interface{}, len(v.claims))
+	for key, value := range v.claims {
+		claims[key] = value
+	}
+	return claims, nil
+}
+
+type tenantClaims struct {
+	Tenant string `json:"tenant"`
+	Seats  int    `json:"seats"`
+}
+
+type claimsResource struct{}
+
+func (c *claimsResource) Routes() route.Routes {
+	return route.Routes{{
+		URL:    "/",
+		Method: resource.Get,
+		Allow:  permission.Basic,
+		Handler: func(req request.Request) resource.Response {
+			c := req.Claims()
+			custom, ok := request.CustomClaims[tenantClaims](req)
+			if !ok {
+				return response.InternalError("no custom claims")
+			}
+			seats, _ := request.Claim[int](req, "seats")
+			return response.Payload(map[string]interface{}{
+				"sub":         c.Subject,
+				"aud":         c.Audience,
+				"exp":         c.ExpiresAt.Unix(),
+				"permissions": req.Permission(),
+				"tenant":      custom.Tenant,
+				"seats":       seats,
+			})
+		},
+	}}
+}
+
+func TestCustomClaims(t *testing.T) {
+	require := require.New(t)
+	v := &claimsValidator{map[string]interface{}{
+		"sub":         "alice",
+		"aud":         []interface{}{"api"},
+		"exp":         float64(2000000000),
+		"permissions": float64(permission.Basic),
+		"tenant":      "acme",
+		"seats":       float64(3),
+	}}
+	r := rester.New(rester.WithTokenValidator(v), rester.WithCustomClaims(tenantClaims{}))
+	r.Resource("/", new(claimsResource))
+	require.NoError(r.Build())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(http.StatusOK, w.Code)
+	require.JSONEq(`{
+		"sub": "alice",
+		"aud": ["api"],
+		"exp": 2000000000,
+		"permissions": 4,
+		"tenant": "acme",
+		"seats": 3
+	}`, w.Body.String())
+
+	v.claims["tenant"] = 1
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(http.StatusUnauthorized, w.Code)
+
+	r = rester.New(rester.WithTokenValidator(v), rester.WithCustomClaims(""))
+	require.Error(r.Build())
+}
+
+func TestWithPermissionMapper(t *testing.T) {
+	require := require.New(t)
+	v := &claimsValidator{map[string]interface{}{
+		"roles": []interface{}{"billing"},
+	}}
+	r := rester.New(
+		rester.WithTokenValidator(v),
+		rester.WithPermissionMapper(permission.Roles("roles", map[string]permission.Permissions{
+			"admin":   permission.Admin,
+			"billing": permission.Basic,
+		})),
+	)
+	r.Resource("/", new(adminResource))
+	require.NoError(r.Build())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+	require.Equal(http.StatusForbidden, w.Code)
+
+	v.claims["roles"] = []interface{}{"admin", "billing"}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+	require.Equal(http.StatusOK, w.Code)
+
+	v.claims["roles"] = 1
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+	require.Equal(http.StatusUnauthorized, w.Code)
+
+	r = rester.New(rester.WithTokenValidator(v), rester.WithPermissionMapper(nil))
+	require.Error(r.Build())
+}
+
+var billing = permission.Register("billing")
+
+type billingResource struct{}
+
+func (b *billingResource) Routes() route.Routes {
+	return route.Routes{{
+		URL:     "/invoices",
+		Method:  resource.Get,
+		Roles:   []string{"billing"},
+		Handler: index,
+	}}
+}
+
+func TestRouteRoles(t *testing.T) {
+	require := require.New(t)
+	v := &claimsValidator{map[string]interface{}{
+		"permissions": float64(permission.Basic),
+	}}
+	r := rester.New(rester.WithTokenValidator(v))
+	r.Resource("/", new(billingResource))
+	require.NoError(r.Build())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/invoices", nil))
+	require.Equal(http.StatusForbidden, w.Code)
+
+	v.claims["roles"] = []interface{}{billing.String()}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/invoices", nil))
+	require.Equal(http.StatusOK, w.Code)
+
+	doc := r.OpenAPI()
+	require.Equal([]string{"billing"}, doc.Paths["/invoices"]["get"].Roles)
+}
+
+func TestDefaultRoleMapper(t *testing.T) {
+	require := require.New(t)
+	v := &claimsValidator{map[string]interface{}{
+		"permissions": float64(permission.Basic),
+		// a roles claim not meant for the role registry
+		"roles": map[string]interface{}{"realm": []interface{}{"user"}},
+	}}
+	r := rester.New(rester.WithTokenValidator(v))
+	r.Resource("/", new(strictResource))
+	require.NoError(r.Build())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/basic", nil))
+	require.Equal(http.StatusOK, w.Code)
+
+	// a mapper set explicitly rejects the claims it can't parse
+	r = rester.New(rester.WithTokenValidator(v),
+		rester.WithRoleMapper(permission.RoleNames("roles")))
+	r.Resource("/", new(strictResource))
+	require.NoError(r.Build())
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/basic", nil))
+	require.Equal(http.StatusUnauthorized, w.Code)
+}
+
+type unknownRoleResource struct{}
+
+func (u *unknownRoleResource) Routes() route.Routes {
+	return route.Routes{{
+		URL:     "/",
+		Method:  resource.Get,
+		Roles:   []string{"missing"},
+		Handler: index,
+	}}
+}
+
+func TestBuildWithUnknownRole(t *testing.T) {
+	require := require.New(t)
+	r := rester.New()
+	r.Resource("/", new(unknownRoleResource))
+	err := r.Build()
+	require.Error(err)
+	require.Contains(err.Error(), `unknown role "missing"`)
+}
+
+type strictResource struct{}
+
+func (s *strictResource) Routes() route.Routes {
+	return route.Routes{{
+		URL:     "/basic",
+		Method:  resource.Get,
+		Allow:   permission.Basic,
+		Handler: index,
+	}, {
+		URL:        "/both",
+		Method:     resource.Get,
+		Allow:      permission.Basic | permission.Super,
+		RequireAll: true,
+		Handler:    index,
+	}}
+}
+
+func TestWithHierarchy(t *testing.T) {
+	require := require.New(t)
+	v := &claimsValidator{map[string]interface{}{
+		"permissions": float64(permission.Admin),
+	}}
+	get := func(r *rester.Rester, url string) int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		return w.Code
+	}
+
+	r := rester.New(rester.WithTokenValidator(v))
+	r.Resource("/", new(strictResource))
+	require.NoError(r.Build())
+	require.Equal(http.StatusForbidden, get(r, "/basic"))
+
+	r = rester.New(
+		rester.WithTokenValidator(v),
+		rester.WithHierarchy(permission.DefaultHierarchy),
+	)
+	r.Resource("/", new(strictResource))
+	require.NoError(r.Build())
+	require.Equal(http.StatusOK, get(r, "/basic"))
+	require.Equal(http.StatusForbidden, get(r, "/both"))
+
+	v.claims["permissions"] = float64(permission.Super)
+	require.Equal(http.StatusOK, get(r, "/both"))
+}
+
+type documentResource struct{}
+
+func (d *documentResource) Routes() route.Routes {
+	return route.Routes{{
+		URL:     "/documents/{id}",
+		Method:  resource.Put,
+		Allow:   permission.Basic | permission.Admin,
+		Handler: index,
+		Policy: policy.AnyOf(
+			policy.OwnerOf("id", "sub"),
+			policy.HasPermission(permission.Admin),
+		),
+	}}
+}
+
+func TestRoutePolicy(t *testing.T) {
+	require := require.New(t)
+	v := &claimsValidator{map[string]interface{}{
+		"sub":         "alice",
+		"permissions": float64(permission.Basic),
+	}}
+	r := rester.New(rester.WithTokenValidator(v))
+	r.Resource("/", new(documentResource))
+	require.NoError(r.Build())
+	put := func(url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, url, nil))
+		return w
+	}
+
+	require.Equal(http.StatusOK, put("/documents/alice").Code)
+	w := put("/documents/bob")
+	require.Equal(http.StatusForbidden, w.Code)
+	require.JSONEq(`{
+		"error": "you don't have permission to access this resource",
+		"details": {"code": "missing_permission"}
+	}`, w.Body.String())
+
+	v.claims["permissions"] = float64(permission.Basic | permission.Admin)
+	require.Equal(http.StatusOK, put("/documents/bob").Code)
+}
+
+func TestRoutePolicyProblem(t *testing.T) {
+	require := require.New(t)
+	v := &claimsValidator{map[string]interface{}{
+		"sub":         "alice",
+		"permissions": float64(permission.Basic),
+	}}
+	r := rester.New(rester.WithTokenValidator(v), rester.WithProblemDetails())
+	r.Resource("/", new(documentResource))
+	require.NoError(r.Build())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/documents/bob", nil))
+	require.Equal(http.StatusForbidden, w.Code)
+	require.JSONEq(`{
+		"type": "about:blank",
+		"title": "Forbidden",
+		"status": 403,
+		"detail": "you don't have permission to access this resource",
+		"code": "missing_permission"
+	}`, w.Body.String())
+}
+
+func TestWithRBAC(t *testing.T) {
+	require := require.New(t)
+	v := &claimsValidator{map[string]interface{}{
+		"permissions": float64(permission.Basic),
+		"roles":       []interface{}{"billing"},
+	}}
+	policy, err := rbac.Parse([]byte(`{"rules": [
+		{"resource": "/", "method": "GET", "url": "/invoices", "roles": ["admin"]}
+	]}`))
+	require.NoError(err)
+	r := rester.New(
+		rester.WithTokenValidator(v),
+		rester.WithRBAC(policy),
+		rester.WithOpenAPI(openapi.Info{Title: "billing", Version: "1.0.0"}),
+	)
+	r.Resource("/", new(billingResource))
+	require.NoError(r.Build())
+	operation := func() *openapi.Operation {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, rester.OpenAPIPath, nil))
+		doc := openapi.Document{}
+		require.NoError(json.NewDecoder(w.Body).Decode(&doc))
+		return doc.Paths["/invoices"]["get"]
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/invoices", nil))
+	require.Equal(http.StatusForbidden, w.Code)
+	require.Equal(permission.Admin, operation().Permissions)
+
+	policy.Rules[0].Roles = []string{"billing"}
+	require.NoError(r.ReloadPolicy(policy))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/invoices", nil))
+	require.Equal(http.StatusOK, w.Code)
+	// the OpenAPI document follows the reloaded policy
+	require.Equal([]string{"billing"}, operation().Roles)
+
+	policy.Rules[0].Roles = []string{"anonymous"}
+	require.Error(r.ReloadPolicy(policy))
+	policy.Rules[0].URL = "/missing"
+	require.Error(r.ReloadPolicy(&rbac.Policy{Rules: policy.Rules}))
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/invoices", nil))
+	require.Equal(http.StatusOK, w.Code)
+}
+
+func TestWithRBACUnknownRoute(t *testing.T) {
+	require := require.New(t)
+	policy := &rbac.Policy{Rules: []rbac.Rule{{
+		Resource: "/", Method: "GET", URL: "/missing", Roles: []string{"admin"},
+	}}}
+	r := rester.New(rester.WithTokenValidator(new(claimsValidator)), rester.WithRBAC(policy))
+	r.Resource("/", new(billingResource))
+	err := r.Build()
+	require.Error(err)
+	require.Contains(err.Error(), "resource /, GET /missing: the rbac rule does not match any route")
+
+	r = rester.New()
+	require.Error(r.ReloadPolicy(policy))
+}
+
+type profile struct {
+	Name  string `json:"name"`
+	Email string `json:"email" rester:"perm=admin"`
+}
+
+type profileResource struct{}
+
+func (p *profileResource) Routes() route.Routes {
+	return route.Routes{{
+		URL:    "/profile",
+		Method: resource.Get,
+		Allow:  permission.Basic,
+		Handler: func(request.Request) resource.Response {
+			return response.Payload(&profile{"alice", "alice@example.com"})
+		},
+	}}
+}
+
+func TestResponseFiltering(t *testing.T) {
+	require := require.New(t)
+	v := &claimsValidator{map[string]interface{}{
+		"permissions": float64(permission.Basic),
+	}}
+	r := rester.New(rester.WithTokenValidator(v))
+	r.Resource("/", new(profileResource))
+	require.NoError(r.Build())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/profile", nil))
+	require.Equal(http.StatusOK, w.Code)
+	require.JSONEq(`{"name":"alice"}`, w.Body.String())
+
+	v.claims["permissions"] = float64(permission.Basic | permission.Admin)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/profile", nil))
+	require.JSONEq(`{"name":"alice","email":"alice@example.com"}`, w.Body.String())
+}
+
+func TestWithAuditSink(t *testing.T) {
+	require := require.New(t)
+	sink := new(audit.Memory)
+	v := &claimsValidator{map[string]interface{}{
+		"sub":         "alice",
+		"permissions": float64(permission.Basic),
+	}}
+	r := rester.New(rester.WithTokenValidator(v), rester.WithAuditSink(sink))
+	r.Resource("/", new(adminResource))
+	r.Resource("/public", new(testResource))
+	require.NoError(r.Build())
+
+	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	v.claims["permissions"] = float64(permission.Admin)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/public/test", nil))
+
+	events := sink.Events()
+	require.Len(events, 2)
+	for i := range events {
+		require.False(events[i].Time.IsZero())
+		events[i].Time = time.Time{}
+	}
+	require.Equal([]audit.Event{{
+		Route:    "/admin",
+		Method:   http.MethodGet,
+		Subject:  "alice",
+		Required: permission.Admin,
+		Held:     permission.Basic,
+		Decision: audit.Deny,
+		Reason:   "you don't have permission to access this resource",
+		ClientIP: "10.0.0.1",
+	}, {
+		Route:    "/admin",
+		Method:   http.MethodGet,
+		Subject:  "alice",
+		Required: permission.Admin,
+		Held:     permission.Admin,
+		Decision: audit.Allow,
+		ClientIP: "10.0.0.1",
+	}}, events)
+}
+
+func TestWithAuditSinkTokenRejected(t *testing.T) {
+	require := require.New(t)
+	sink := new(audit.Memory)
+	v := &validator{errVerify: errors.New("token expired")}
+	r := rester.New(rester.WithTokenValidator(v), rester.WithAuditSink(sink))
+	r.Resource("/", new(adminResource))
+	require.NoError(r.Build())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+	require.Equal(http.StatusUnauthorized, w.Code)
+	events := sink.Events()
+	require.Len(events, 1)
+	require.Equal(audit.Deny, events[0].Decision)
+	require.Equal("/admin", events[0].Route)
+	require.Equal(permission.Admin, events[0].Required)
+	require.Equal("token expired", events[0].Reason)
+	require.Empty(events[0].Subject)
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/hoenirvili/rester/handler"
 	"github.com/hoenirvili/rester/permission"
+	"github.com/hoenirvili/rester/policy"
 	"github.com/hoenirvili/rester/query"
 )
 
@@ -14,6 +15,17 @@ type Route struct {
 	// Allow defines a permission bit flag that can be set
 	// To specify what users can access this resource
 	Allow permission.Permissions
+	// Roles holds the names of the roles registered with permission.Register
+	// that can access this resource, the token must have at least one of them
+	// If both Allow and Roles are set, the token must satisfy both
+	Roles []string
+	// RequireAll is true if the token must have all the permission bits
+	// of Allow and all the Roles instead of any of them
+	RequireAll bool
+	// Policy is evaluated after the permission and role checks
+	// If the policy denies the request a http.StatusForbidden
+	// response is returned holding the reason code of the denial
+	Policy policy.Policy
 	// Method is the main http method that the route will respond to
 	Method string
 	// URL holds the relative URL of the resource
@@ -24,6 +36,14 @@ type Route struct {
 	// QueryPairs holds a list of query parameters key and value used for
 	// retrieving different types of values
 	QueryPairs query.Pairs
+	// Body declares the type of the request body, like Body: user{}
+	// If set, every request body is decoded into a new value of this type
+	// and validated before calling the Handler
+	// A body that cannot be decoded will trigger response.BadRequest
+	// and a body that does not respect it's validation rules will
+	// trigger a http.StatusUnprocessableEntity response
+	// The value can be retrieved in the handler using request.Bound
+	Body interface{}
 	// Middlewares list of middlewares that will be executed first one by one
 	// like a chain before executing the main Handler
 	Middlewares []func(http.Handler) http.Handler
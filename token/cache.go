@@ -0,0 +1,103 @@
+package token
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+)
+
+// cache is a bounded least recently used cache of verified tokens
+// A nil cache never holds any token
+type cache struct {
+	size    int
+	mu      sync.Mutex
+	order   *list.List
+	entries map[[sha256.Size]byte]*list.Element
+	// generation changes every time the cache is purged so the tokens
+	// verified before the purge are not added back
+	generation uint64
+}
+
+type entry struct {
+	key    [sha256.Size]byte
+	claims *Claims
+}
+
+// WithCache caches the claims of the last size verified tokens so
+// the signature of a token is not verified on every request
+// The time based claims and the revocation are still checked every time
+// The cache of a JWKS validator is purged when the key set changes
+func WithCache(size int) Option {
+	return func(j *JWT) {
+		if size <= 0 {
+			j.cache = nil
+			return
+		}
+		j.cache = &cache{
+			size:    size,
+			order:   list.New(),
+			entries: make(map[[sha256.Size]byte]*list.Element, size),
+		}
+	}
+}
+
+func (c *cache) get(token string) (*Claims, bool) {
+	if c == nil {
+		return nil, false
+	}
+	key := sha256.Sum256([]byte(token))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*entry).claims, true
+}
+
+// gen returns the current generation of the cache
+func (c *cache) gen() uint64 {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// purge removes all cached tokens
+func (c *cache) purge() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	c.order.Init()
+	c.entries = make(map[[sha256.Size]byte]*list.Element, c.size)
+}
+
+// add caches the claims of a token verified while the cache had
+// the given generation, if the cache was purged since they are dropped
+func (c *cache) add(token string, claims *Claims, gen uint64) {
+	if c == nil {
+		return
+	}
+	key := sha256.Sum256([]byte(token))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.generation {
+		return
+	}
+	if elem, ok := c.entries[key]; ok {
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&entry{key, claims})
+	for c.order.Len() > c.size {
+		last := c.order.Back()
+		c.order.Remove(last)
+		delete(c.entries, last.Value.(*entry).key)
+	}
+}
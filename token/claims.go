@@ -9,12 +9,42 @@ import (
 	"github.com/hoenirvili/rester/permission"
 )
 
+var (
+	// ErrExpired returned when the token exp claim is in the past
+	ErrExpired = errors.New("token is expired")
+	// ErrNotValidYet returned when the token nbf claim is in the future
+	ErrNotValidYet = errors.New("token is not valid yet")
+	// ErrIssuedInFuture returned when the token iat claim is in the future
+	ErrIssuedInFuture = errors.New("token is issued in the future")
+	// ErrNoIssuedAt returned when a maximum age is required
+	// but the token has no iat claim
+	ErrNoIssuedAt = errors.New("no iat found in the jwt token")
+	// ErrTooOld returned when the token is older than the maximum age
+	ErrTooOld = errors.New("token exceeds the maximum age")
+	// ErrInvalidIssuer returned when the token iss claim is not accepted
+	ErrInvalidIssuer = errors.New("token issuer is not accepted")
+	// ErrInvalidAudience returned when the token aud claim is not accepted
+	ErrInvalidAudience = errors.New("token audience is not accepted")
+)
+
+// rules holds the extra validations of the registered claims
+type rules struct {
+	issuers   []string
+	audiences []string
+	leeway    time.Duration
+	maxAge    time.Duration
+	// optional is true if the "permissions" claim is not required
+	optional bool
+	now      func() time.Time
+}
+
 type Claims struct {
 	mapClaims jwt.MapClaims
+	rules     *rules
 }
 
 func NewClaims() *Claims {
-	return &Claims{make(jwt.MapClaims)}
+	return &Claims{make(jwt.MapClaims), &rules{now: time.Now}}
 }
 
 func (c *Claims) VerifyPermissions() error {
@@ -22,7 +52,11 @@ func (c *Claims) VerifyPermissions() error {
 	if !ok {
 		return errors.New("no permission found in the jwt token")
 	}
-	v := permission.Permissions(p.(float64))
+	n, ok := p.(float64)
+	if !ok {
+		return errors.New("invalid permissions value, value is not a number")
+	}
+	v := permission.Permissions(n)
 	if !v.Valid() {
 		return errors.New("invalid permissions value, value is not supported")
 	}
@@ -37,14 +71,111 @@ func (c *Claims) VerifyExp() error {
 	return nil
 }
 
+// copy returns a deep copy of the claims map
+func (c *Claims) copy() map[string]interface{} {
+	return deepCopy(map[string]interface{}(c.mapClaims)).(map[string]interface{})
+}
+
+// deepCopy copies the maps and slices of the decoded claim value
+// so callers can't modify the cached claims
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[key] = deepCopy(value)
+		}
+		return m
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, value := range v {
+			list[i] = deepCopy(value)
+		}
+		return list
+	case []string:
+		return append([]string(nil), v...)
+	default:
+		return value
+	}
+}
+
+// number returns the numeric date claim as unix seconds
+func (c *Claims) number(key string) (float64, bool) {
+	switch v := c.mapClaims[key].(type) {
+	case float64:
+		return v, true
+	case json.Number:
+		n, err := v.Float64()
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func invalid(err error, flags uint32) error {
+	return &jwt.ValidationError{Inner: err, Errors: flags}
+}
+
+func contains(accepted []string, value string) bool {
+	for _, a := range accepted {
+		if a == value {
+			return true
+		}
+	}
+	return false
+}
+
+// audience checks if any token audience is accepted
+func (c *Claims) audience(accepted []string) bool {
+	switch aud := c.mapClaims["aud"].(type) {
+	case string:
+		return contains(accepted, aud)
+	case []interface{}:
+		for _, a := range aud {
+			if s, ok := a.(string); ok && contains(accepted, s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (c *Claims) Valid() error {
-	now := time.Now().Unix()
-	if !c.mapClaims.VerifyExpiresAt(now, true) {
-		return &jwt.ValidationError{
-			Inner:  errors.New("token is expired"),
-			Errors: jwt.ValidationErrorExpired,
+	r := c.rules
+	if r == nil {
+		r = &rules{now: time.Now}
+	}
+	now := float64(r.now().Unix())
+	leeway := r.leeway.Seconds()
+
+	exp, ok := c.number("exp")
+	if !ok || now > exp+leeway {
+		return invalid(ErrExpired, jwt.ValidationErrorExpired)
+	}
+	if nbf, ok := c.number("nbf"); ok && now+leeway < nbf {
+		return invalid(ErrNotValidYet, jwt.ValidationErrorNotValidYet)
+	}
+	iat, ok := c.number("iat")
+	if ok && now+leeway < iat {
+		return invalid(ErrIssuedInFuture, jwt.ValidationErrorIssuedAt)
+	}
+	if r.maxAge > 0 {
+		if !ok {
+			return invalid(ErrNoIssuedAt, jwt.ValidationErrorIssuedAt)
+		}
+		if now-leeway > iat+r.maxAge.Seconds() {
+			return invalid(ErrTooOld, jwt.ValidationErrorIssuedAt)
 		}
 	}
+	if len(r.issuers) > 0 {
+		iss, _ := c.mapClaims["iss"].(string)
+		if !contains(r.issuers, iss) {
+			return invalid(ErrInvalidIssuer, jwt.ValidationErrorIssuer)
+		}
+	}
+	if len(r.audiences) > 0 && !c.audience(r.audiences) {
+		return invalid(ErrInvalidAudience, jwt.ValidationErrorAudience)
+	}
 	return nil
 }
 
@@ -52,8 +183,10 @@ func (c *Claims) UnmarshalJSON(b []byte) error {
 	if err := json.Unmarshal(b, &c.mapClaims); err != nil {
 		return err
 	}
-	if err := c.VerifyPermissions(); err != nil {
-		return err
+	if c.rules == nil || !c.rules.optional {
+		if err := c.VerifyPermissions(); err != nil {
+			return err
+		}
 	}
 	if err := c.VerifyExp(); err != nil {
 		return err
@@ -0,0 +1,168 @@
+package token_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gojwt "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoenirvili/rester/permission"
+	"github.com/hoenirvili/rester/token"
+)
+
+func hammer(t *testing.T, jwt *token.JWT, i *token.Issuer) {
+	require := require.New(t)
+	const users = 16
+	tokens := make([]string, users)
+	for u := range tokens {
+		access, err := i.Access(fmt.Sprintf("user-%d", u), permission.Basic)
+		require.NoError(err)
+		tokens[u] = access
+	}
+
+	var (
+		wg       sync.WaitGroup
+		failures int32
+	)
+	for g := 0; g < 64; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for n := 0; n < 50; n++ {
+				u := (g + n) % users
+				claims, err := jwt.Verify(bearer(tokens[u]))
+				if err != nil || claims["sub"] != fmt.Sprintf("user-%d", u) {
+					atomic.AddInt32(&failures, 1)
+					continue
+				}
+				// callers can modify the returned claims
+				claims["sub"] = "changed"
+			}
+		}(g)
+	}
+	wg.Wait()
+	require.Zero(atomic.LoadInt32(&failures))
+}
+
+func TestVerifyConcurrent(t *testing.T) {
+	require := require.New(t)
+	public, err := gojwt.ParseRSAPublicKeyFromPEM(pub)
+	require.NoError(err)
+	hammer(t, token.NewJWT(public), issuer(require))
+}
+
+func TestVerifyConcurrentWithCache(t *testing.T) {
+	require := require.New(t)
+	public, err := gojwt.ParseRSAPublicKeyFromPEM(pub)
+	require.NoError(err)
+	hammer(t, token.NewJWT(public, token.WithCache(4)), issuer(require))
+	hammer(t, token.NewJWT(public, token.WithCache(1024)), issuer(require))
+}
+
+func TestVerifyCache(t *testing.T) {
+	require := require.New(t)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(err)
+	var fetches int32
+	set := keySet(require, rsaJWK("1", key))
+	source := token.KeySetFunc(func() ([]byte, error) {
+		atomic.AddInt32(&fetches, 1)
+		return set, nil
+	})
+
+	// with zero intervals every key lookup fetches the key set
+	jwks, err := token.NewJWKS(source,
+		token.WithRefreshInterval(0),
+		token.WithMinRefetchInterval(0),
+		token.WithJWTOptions(token.WithCache(1)),
+	)
+	require.NoError(err)
+	i := token.NewIssuer(gojwt.SigningMethodRS256, key, token.WithKeyID("1"))
+	access, err := i.Access("alice", permission.Basic)
+	require.NoError(err)
+	first := bearer(access)
+	access, err = i.Access("bob", permission.Basic)
+	require.NoError(err)
+	second := bearer(access)
+
+	_, err = jwks.Verify(first)
+	require.NoError(err)
+	_, err = jwks.Verify(first)
+	require.NoError(err)
+	require.EqualValues(2, atomic.LoadInt32(&fetches))
+
+	// the second token evicts the first one
+	_, err = jwks.Verify(second)
+	require.NoError(err)
+	_, err = jwks.Verify(first)
+	require.NoError(err)
+	require.EqualValues(4, atomic.LoadInt32(&fetches))
+}
+
+func TestVerifyCacheRevalidates(t *testing.T) {
+	require := require.New(t)
+	public, err := gojwt.ParseRSAPublicKeyFromPEM(pub)
+	require.NoError(err)
+	d := token.NewDenylist(time.Hour)
+	jwt := token.NewJWT(public, token.WithCache(8), token.WithRevocation(d))
+
+	access, err := issuer(require, token.WithAccessTTL(time.Second)).Access("alice", permission.Basic)
+	require.NoError(err)
+	_, err = jwt.Verify(bearer(access))
+	require.NoError(err)
+
+	require.NoError(d.RevokeSubject("alice", time.Now().Add(time.Second)))
+	_, err = jwt.Verify(bearer(access))
+	require.True(errors.Is(err, token.ErrRevoked))
+}
+
+func TestVerifyCacheKeyRotation(t *testing.T) {
+	require := require.New(t)
+	old, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(err)
+	rotated, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(err)
+	var current atomic.Value
+	current.Store(keySet(require, rsaJWK("old", old)))
+	source := token.KeySetFunc(func() ([]byte, error) {
+		return current.Load().([]byte), nil
+	})
+
+	jwks, err := token.NewJWKS(source, token.WithJWTOptions(token.WithCache(8)))
+	require.NoError(err)
+	access, err := token.NewIssuer(gojwt.SigningMethodRS256, old, token.WithKeyID("old")).
+		Access("alice", permission.Basic)
+	require.NoError(err)
+	_, err = jwks.Verify(bearer(access))
+	require.NoError(err)
+
+	// the cached token is dropped once its key is removed from the set
+	current.Store(keySet(require, rsaJWK("new", rotated)))
+	require.NoError(jwks.Refresh())
+	_, err = jwks.Verify(bearer(access))
+	require.True(errors.Is(err, token.ErrUnknownKey))
+}
+
+func TestVerifyCacheCopiesClaims(t *testing.T) {
+	require := require.New(t)
+	public, err := gojwt.ParseRSAPublicKeyFromPEM(pub)
+	require.NoError(err)
+	jwt := token.NewJWT(public, token.WithCache(8))
+	access, err := issuer(require, token.WithAudienceClaim("a", "b")).Access("alice", permission.Basic)
+	require.NoError(err)
+
+	claims, err := jwt.Verify(bearer(access))
+	require.NoError(err)
+	claims["aud"].([]interface{})[0] = "changed"
+
+	claims, err = jwt.Verify(bearer(access))
+	require.NoError(err)
+	require.Equal([]interface{}{"a", "b"}, claims["aud"])
+}
@@ -0,0 +1,54 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"errors"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// ErrEdDSAVerification returned when the Ed25519 signature is not valid
+var ErrEdDSAVerification = errors.New("ed25519: verification error")
+
+// SigningMethodEd25519 implements the EdDSA signing method using Ed25519 keys
+type SigningMethodEd25519 struct{}
+
+// SigningMethodEdDSA is the EdDSA signing method registered in jwt-go
+var SigningMethodEdDSA = &SigningMethodEd25519{}
+
+func init() {
+	jwt.RegisterSigningMethod(SigningMethodEdDSA.Alg(), func() jwt.SigningMethod {
+		return SigningMethodEdDSA
+	})
+}
+
+// Alg returns the name of the signing method
+func (m *SigningMethodEd25519) Alg() string { return "EdDSA" }
+
+// Verify checks the signature of the signing string using an ed25519.PublicKey
+func (m *SigningMethodEd25519) Verify(signingString, signature string, key interface{}) error {
+	public, ok := key.(ed25519.PublicKey)
+	if !ok || len(public) != ed25519.PublicKeySize {
+		return jwt.ErrInvalidKeyType
+	}
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(public, []byte(signingString), sig) {
+		return ErrEdDSAVerification
+	}
+	return nil
+}
+
+// Sign signs the signing string using an ed25519.PrivateKey
+func (m *SigningMethodEd25519) Sign(signingString string, key interface{}) (string, error) {
+	private, ok := key.(ed25519.PrivateKey)
+	if !ok || len(private) != ed25519.PrivateKeySize {
+		return "", jwt.ErrInvalidKeyType
+	}
+	sig := ed25519.Sign(private, []byte(signingString))
+	return jwt.EncodeSegment(sig), nil
+}
+
+var _ jwt.SigningMethod = (*SigningMethodEd25519)(nil)
@@ -0,0 +1,99 @@
+package token
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go/request"
+)
+
+// Extractor extracts the raw token from a part of the request
+type Extractor interface {
+	// Source returns the name of the part of the request
+	// that holds the token, like "cookie:session"
+	Source() string
+	// ExtractToken returns the token found in the request or
+	// request.ErrNoTokenInRequest if there is none
+	ExtractToken(r *http.Request) (string, error)
+}
+
+type extractor struct {
+	source  string
+	extract func(r *http.Request) string
+}
+
+func (e extractor) Source() string { return e.source }
+
+func (e extractor) ExtractToken(r *http.Request) (string, error) {
+	if token := e.extract(r); token != "" {
+		return token, nil
+	}
+	return "", request.ErrNoTokenInRequest
+}
+
+// FromHeader extracts the token from the header with the given name
+// If prefix is not empty, the header value must start with it and
+// the prefix is stripped, the prefix is matched case-insensitively
+func FromHeader(name, prefix string) Extractor {
+	return extractor{"header:" + name, func(r *http.Request) string {
+		value := strings.TrimSpace(r.Header.Get(name))
+		if prefix == "" {
+			return value
+		}
+		if len(value) < len(prefix) || !strings.EqualFold(value[:len(prefix)], prefix) {
+			return ""
+		}
+		return strings.TrimSpace(value[len(prefix):])
+	}}
+}
+
+// FromAuthorization extracts the bearer token from the Authorization header
+func FromAuthorization() Extractor {
+	return FromHeader("Authorization", "Bearer ")
+}
+
+// FromCookie extracts the token from the cookie with the given name
+func FromCookie(name string) Extractor {
+	return extractor{"cookie:" + name, func(r *http.Request) string {
+		cookie, err := r.Cookie(name)
+		if err != nil {
+			return ""
+		}
+		return cookie.Value
+	}}
+}
+
+// FromQuery extracts the token from the url query param with the given name
+// e.g. FromQuery("access_token")
+func FromQuery(param string) Extractor {
+	return extractor{"query:" + param, func(r *http.Request) string {
+		return r.URL.Query().Get(param)
+	}}
+}
+
+// WithExtractors sets the extractors used to find the token in the request
+// The extractors are tried in the given order and the first token found is used
+// The default is FromAuthorization()
+func WithExtractors(extractors ...Extractor) Option {
+	return func(j *JWT) { j.extractors = extractors }
+}
+
+// extracted is a request.Extractor returning an already extracted token
+type extracted string
+
+func (e extracted) ExtractToken(*http.Request) (string, error) { return string(e), nil }
+
+// extract returns the first token found by the extractors and it's source
+func (j *JWT) extract(r *http.Request) (string, string, error) {
+	for _, e := range j.extractors {
+		token, err := e.ExtractToken(r)
+		if err == request.ErrNoTokenInRequest {
+			continue
+		}
+		if err != nil {
+			return "", "", err
+		}
+		return token, e.Source(), nil
+	}
+	return "", "", request.ErrNoTokenInRequest
+}
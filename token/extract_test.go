@@ -0,0 +1,105 @@
+package token_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gojwt "github.com/dgrijalva/jwt-go"
+	jwtrequest "github.com/dgrijalva/jwt-go/request"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoenirvili/rester"
+	"github.com/hoenirvili/rester/permission"
+	"github.com/hoenirvili/rester/request"
+	"github.com/hoenirvili/rester/resource"
+	"github.com/hoenirvili/rester/response"
+	"github.com/hoenirvili/rester/route"
+	"github.com/hoenirvili/rester/token"
+)
+
+func TestExtractors(t *testing.T) {
+	require := require.New(t)
+	public, err := gojwt.ParseRSAPublicKeyFromPEM(pub)
+	require.NoError(err)
+	access, err := issuer(require).Access("alice", permission.Basic)
+	require.NoError(err)
+
+	jwt := token.NewJWT(public, token.WithExtractors(
+		token.FromAuthorization(),
+		token.FromHeader("X-Token", ""),
+		token.FromCookie("session"),
+		token.FromQuery("access_token"),
+	))
+
+	tests := []struct {
+		name   string
+		setup  func(r *http.Request)
+		source string
+	}{
+		{"authorization", func(r *http.Request) {
+			r.Header.Set("Authorization", "bearer "+access)
+			r.AddCookie(&http.Cookie{Name: "session", Value: "invalid"})
+		}, "header:Authorization"},
+		{"custom header", func(r *http.Request) {
+			r.Header.Set("X-Token", access)
+		}, "header:X-Token"},
+		{"cookie", func(r *http.Request) {
+			r.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+			r.AddCookie(&http.Cookie{Name: "session", Value: access})
+		}, "cookie:session"},
+		{"query", func(r *http.Request) {
+			r.URL.RawQuery = "access_token=" + access
+		}, "query:access_token"},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		test.setup(req)
+		claims, source, err := jwt.VerifySource(req)
+		require.NoError(err, test.name)
+		require.Equal("alice", claims["sub"], test.name)
+		require.Equal(test.source, source, test.name)
+	}
+
+	_, _, err = jwt.VerifySource(httptest.NewRequest(http.MethodGet, "/", nil))
+	require.True(errors.Is(err, jwtrequest.ErrNoTokenInRequest))
+}
+
+type sourceResource struct{}
+
+func (sourceResource) Routes() route.Routes {
+	return route.Routes{{
+		URL:    "/",
+		Method: resource.Get,
+		Allow:  permission.Basic,
+		Handler: func(req request.Request) resource.Response {
+			return response.Payload(req.TokenSource())
+		},
+	}}
+}
+
+func TestTokenSource(t *testing.T) {
+	require := require.New(t)
+	public, err := gojwt.ParseRSAPublicKeyFromPEM(pub)
+	require.NoError(err)
+	access, err := issuer(require).Access("alice", permission.Basic)
+	require.NoError(err)
+
+	var _ rester.SourceValidator = token.NewJWT(public)
+	r := rester.New(rester.WithTokenValidator(
+		token.NewJWT(public, token.WithExtractors(token.FromCookie("session"))),
+	))
+	r.Resource("/", sourceResource{})
+	require.NoError(r.Build())
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: access})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(http.StatusOK, w.Code)
+	body, err := ioutil.ReadAll(w.Body)
+	require.NoError(err)
+	require.JSONEq(`"cookie:session"`, string(body))
+}
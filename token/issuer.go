@@ -0,0 +1,246 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/hoenirvili/rester/permission"
+)
+
+// ErrInvalidRefreshToken returned when the refresh token is unknown,
+// expired or was already used
+var ErrInvalidRefreshToken = errors.New("refresh token is not valid")
+
+// Session holds the information stored for every refresh token
+type Session struct {
+	// Subject is the "sub" claim of the issued access tokens
+	Subject string
+	// Permissions is the "permissions" claim of the issued access tokens
+	Permissions permission.Permissions
+	// Roles is the "roles" claim of the issued access tokens
+	Roles permission.Set
+	// Expires is the time when the refresh token expires
+	Expires time.Time
+	// Authenticated is the time when the subject was authenticated
+	// This is kept when the refresh token is rotated
+	Authenticated time.Time
+}
+
+// RefreshStore persists the refresh tokens issued by an Issuer
+// The refresh tokens are never stored in plain text, only their hash
+type RefreshStore interface {
+	// Save stores the session under the given refresh token hash
+	Save(hash string, s Session) error
+	// Take returns and removes the session stored under the given hash
+	// If there is none, ErrInvalidRefreshToken should be returned
+	Take(hash string) (Session, error)
+}
+
+// Pair holds the tokens returned to the client
+type Pair struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// Issuer mints signed access tokens holding the claims expected
+// by the token validators and rotating refresh tokens
+type Issuer struct {
+	method     jwt.SigningMethod
+	key        interface{}
+	kid        string
+	issuer     string
+	audience   []string
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	store      RefreshStore
+	revoked    Revocation
+	now        func() time.Time
+}
+
+// IssuerOption defines a setter callback type to set an underlying Issuer option
+type IssuerOption func(i *Issuer)
+
+// WithKeyID sets the "kid" header of the issued access tokens
+func WithKeyID(kid string) IssuerOption {
+	return func(i *Issuer) { i.kid = kid }
+}
+
+// WithIssuerClaim sets the "iss" claim of the issued access tokens
+func WithIssuerClaim(iss string) IssuerOption {
+	return func(i *Issuer) { i.issuer = iss }
+}
+
+// WithAudienceClaim sets the "aud" claim of the issued access tokens
+func WithAudienceClaim(aud ...string) IssuerOption {
+	return func(i *Issuer) { i.audience = aud }
+}
+
+// WithAccessTTL sets how long the access tokens are valid
+// The default is 15 minutes
+func WithAccessTTL(d time.Duration) IssuerOption {
+	return func(i *Issuer) { i.accessTTL = d }
+}
+
+// WithRefreshTTL sets how long the refresh tokens are valid
+// The default is 7 days
+func WithRefreshTTL(d time.Duration) IssuerOption {
+	return func(i *Issuer) { i.refreshTTL = d }
+}
+
+// WithRefreshStore sets the store of the refresh tokens
+// If the store is nil no refresh tokens will be issued
+// The default is a MemoryStore
+func WithRefreshStore(store RefreshStore) IssuerOption {
+	return func(i *Issuer) { i.store = store }
+}
+
+// WithRefreshRevocation rejects the refresh tokens of the subjects
+// that were revoked after they authenticated
+func WithRefreshRevocation(r Revocation) IssuerOption {
+	return func(i *Issuer) { i.revoked = r }
+}
+
+// NewIssuer returns an Issuer that signs the access tokens
+// using the given signing method and private key
+// e.g. NewIssuer(jwt.SigningMethodRS256, privateKey)
+func NewIssuer(method jwt.SigningMethod, key interface{}, opts ...IssuerOption) *Issuer {
+	i := &Issuer{
+		method:     method,
+		key:        key,
+		accessTTL:  15 * time.Minute,
+		refreshTTL: 7 * 24 * time.Hour,
+		store:      NewMemoryStore(),
+		now:        time.Now,
+	}
+	for _, setter := range opts {
+		setter(i)
+	}
+	return i
+}
+
+// random returns n random bytes encoded in base64 url format
+func random(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hash(refresh string) string {
+	sum := sha256.Sum256([]byte(refresh))
+	return hex.EncodeToString(sum[:])
+}
+
+// Access returns a signed access token for the subject
+// having the given permissions and roles
+// The roles are encoded by name in the "roles" claim
+func (i *Issuer) Access(subject string, p permission.Permissions, roles ...permission.Role) (string, error) {
+	return i.access(subject, p, permission.NewSet(roles...))
+}
+
+func (i *Issuer) access(subject string, p permission.Permissions, roles permission.Set) (string, error) {
+	jti, err := random(16)
+	if err != nil {
+		return "", err
+	}
+	now := i.now()
+	claims := jwt.MapClaims{
+		"jti":         jti,
+		"sub":         subject,
+		"permissions": int(p),
+		"iat":         now.Unix(),
+		"nbf":         now.Unix(),
+		"exp":         now.Add(i.accessTTL).Unix(),
+	}
+	if !roles.Empty() {
+		claims["roles"] = roles.Names()
+	}
+	if i.issuer != "" {
+		claims["iss"] = i.issuer
+	}
+	switch len(i.audience) {
+	case 0:
+	case 1:
+		claims["aud"] = i.audience[0]
+	default:
+		claims["aud"] = i.audience
+	}
+	t := jwt.NewWithClaims(i.method, claims)
+	if i.kid != "" {
+		t.Header["kid"] = i.kid
+	}
+	return t.SignedString(i.key)
+}
+
+// Issue returns a new access token and, if the issuer has a
+// refresh store, a new refresh token for the subject
+// The refreshed access tokens keep the same permissions and roles
+func (i *Issuer) Issue(subject string, p permission.Permissions, roles ...permission.Role) (*Pair, error) {
+	return i.issue(Session{
+		Subject:       subject,
+		Permissions:   p,
+		Roles:         permission.NewSet(roles...),
+		Authenticated: i.now(),
+	})
+}
+
+// issue issues the tokens of the session setting its expiration
+func (i *Issuer) issue(s Session) (*Pair, error) {
+	access, err := i.access(s.Subject, s.Permissions, s.Roles)
+	if err != nil {
+		return nil, err
+	}
+	pair := &Pair{
+		AccessToken: access,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(i.accessTTL / time.Second),
+	}
+	if i.store == nil {
+		return pair, nil
+	}
+
+	refresh, err := random(32)
+	if err != nil {
+		return nil, err
+	}
+	s.Expires = i.now().Add(i.refreshTTL)
+	if err := i.store.Save(hash(refresh), s); err != nil {
+		return nil, err
+	}
+	pair.RefreshToken = refresh
+	return pair, nil
+}
+
+// Refresh exchanges the refresh token for a new pair of tokens
+// The refresh token can be used only once
+func (i *Issuer) Refresh(refresh string) (*Pair, error) {
+	if i.store == nil || refresh == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+	s, err := i.store.Take(hash(refresh))
+	if err != nil {
+		return nil, err
+	}
+	if !i.now().Before(s.Expires) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if i.revoked != nil {
+		revoked, err := i.revoked.Revoked("", s.Subject, s.Authenticated)
+		if err != nil {
+			return nil, fmt.Errorf("cannot check if the token is revoked: %w", err)
+		}
+		if revoked {
+			return nil, ErrRevoked
+		}
+	}
+	return i.issue(s)
+}
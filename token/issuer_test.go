@@ -0,0 +1,126 @@
+package token_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	gojwt "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoenirvili/rester/permission"
+	"github.com/hoenirvili/rester/token"
+)
+
+func issuer(r *require.Assertions, opts ...token.IssuerOption) *token.Issuer {
+	private, err := gojwt.ParseRSAPrivateKeyFromPEM(priv)
+	r.NoError(err)
+	return token.NewIssuer(gojwt.SigningMethodRS256, private, opts...)
+}
+
+func bearer(access string) *http.Request {
+	return &http.Request{
+		Header: http.Header{"Authorization": []string{"Bearer " + access}},
+	}
+}
+
+func TestIssuerIssue(t *testing.T) {
+	require := require.New(t)
+	i := issuer(require,
+		token.WithIssuerClaim("auth"),
+		token.WithAudienceClaim("api"),
+		token.WithAccessTTL(time.Minute),
+	)
+	pair, err := i.Issue("alice", permission.Admin)
+	require.NoError(err)
+	require.Equal("Bearer", pair.TokenType)
+	require.EqualValues(60, pair.ExpiresIn)
+	require.NotEmpty(pair.RefreshToken)
+
+	v := jwt(require)
+	_, err = token.NewJWT(nil).Verify(bearer(pair.AccessToken))
+	require.True(errors.Is(err, token.ErrNoKey))
+
+	claims, err := v.Verify(bearer(pair.AccessToken))
+	require.NoError(err)
+	require.Equal("alice", claims["sub"])
+	require.EqualValues(permission.Admin, claims["permissions"])
+	require.NotEmpty(claims["jti"])
+
+	public, err := gojwt.ParseRSAPublicKeyFromPEM(pub)
+	require.NoError(err)
+	_, err = token.NewJWT(public, token.WithIssuer("auth"), token.WithAudience("api")).
+		Verify(bearer(pair.AccessToken))
+	require.NoError(err)
+}
+
+func TestIssuerRefresh(t *testing.T) {
+	require := require.New(t)
+	i := issuer(require)
+	pair, err := i.Issue("alice", permission.Basic)
+	require.NoError(err)
+
+	next, err := i.Refresh(pair.RefreshToken)
+	require.NoError(err)
+	require.NotEqual(pair.RefreshToken, next.RefreshToken)
+	claims, err := jwt(require).Verify(bearer(next.AccessToken))
+	require.NoError(err)
+	require.Equal("alice", claims["sub"])
+
+	// refresh tokens are rotated so they can be used only once
+	_, err = i.Refresh(pair.RefreshToken)
+	require.True(errors.Is(err, token.ErrInvalidRefreshToken))
+	_, err = i.Refresh("")
+	require.True(errors.Is(err, token.ErrInvalidRefreshToken))
+}
+
+func TestIssuerRefreshExpired(t *testing.T) {
+	require := require.New(t)
+	i := issuer(require, token.WithRefreshTTL(-time.Second))
+	pair, err := i.Issue("alice", permission.Basic)
+	require.NoError(err)
+	_, err = i.Refresh(pair.RefreshToken)
+	require.True(errors.Is(err, token.ErrInvalidRefreshToken))
+}
+
+func TestIssuerWithoutStore(t *testing.T) {
+	require := require.New(t)
+	i := issuer(require, token.WithRefreshStore(nil))
+	pair, err := i.Issue("alice", permission.Basic)
+	require.NoError(err)
+	require.Empty(pair.RefreshToken)
+	_, err = i.Refresh("anything")
+	require.True(errors.Is(err, token.ErrInvalidRefreshToken))
+}
+
+var support = permission.Register("token-support")
+
+func TestIssuerRoles(t *testing.T) {
+	require := require.New(t)
+	i := issuer(require)
+	pair, err := i.Issue("alice", permission.Basic, support)
+	require.NoError(err)
+
+	claims, err := jwt(require).Verify(bearer(pair.AccessToken))
+	require.NoError(err)
+	roles, err := permission.RoleNames("roles")(claims)
+	require.NoError(err)
+	require.True(roles.Has(support))
+
+	// the refreshed access token keeps the roles
+	next, err := i.Refresh(pair.RefreshToken)
+	require.NoError(err)
+	claims, err = jwt(require).Verify(bearer(next.AccessToken))
+	require.NoError(err)
+	roles, err = permission.RoleNames("roles")(claims)
+	require.NoError(err)
+	require.True(roles.Has(support))
+
+	// tokens without roles have no "roles" claim
+	access, err := i.Access("bob", permission.Basic)
+	require.NoError(err)
+	claims, err = jwt(require).Verify(bearer(access))
+	require.NoError(err)
+	require.NotContains(claims, "roles")
+}
@@ -0,0 +1,420 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// jwksMethods holds the algorithms of the keys supported in the key set
+var jwksMethods = append(append(append([]string{}, rsaMethods...), ecdsaMethods...), eddsaMethods...)
+
+// ErrUnknownKey returned when the token was signed by a key
+// that can't be found in the json web key set
+var ErrUnknownKey = errors.New("the token was signed with an unknown key")
+
+// KeySetSource fetches the raw json web key set
+type KeySetSource interface {
+	// Fetch returns the json encoded json web key set
+	Fetch() ([]byte, error)
+}
+
+// KeySetFunc is an adapter to allow the use of ordinary functions as a KeySetSource
+type KeySetFunc func() ([]byte, error)
+
+// Fetch calls f()
+func (f KeySetFunc) Fetch() ([]byte, error) { return f() }
+
+// KeySetBytes returns a source that always returns the given json web key set
+func KeySetBytes(b []byte) KeySetSource {
+	return KeySetFunc(func() ([]byte, error) { return b, nil })
+}
+
+// KeySetFile returns a source that reads the json web key set from
+// the file found at path
+func KeySetFile(path string) KeySetSource {
+	return KeySetFunc(func() ([]byte, error) { return ioutil.ReadFile(path) })
+}
+
+// KeySetURL returns a source that fetches the json web key set from the
+// given url using client
+// If the client is nil, a client with a 10 seconds timeout is used
+func KeySetURL(url string, client *http.Client) KeySetSource {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return KeySetFunc(func() ([]byte, error) {
+		resp, err := client.Get(url)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("cannot fetch the json web key set, got status %d", resp.StatusCode)
+		}
+		return ioutil.ReadAll(resp.Body)
+	})
+}
+
+// JWKS is a jwt token validator that verifies tokens using the keys
+// of a json web key set selected by the "kid" token header
+// The keys are cached and refreshed periodically, stale keys or a token
+// signed by an unknown key will trigger a refresh at most once per
+// MinRefetchInterval, between the attempts the cached keys are used
+type JWKS struct {
+	*JWT
+
+	source             KeySetSource
+	refreshInterval    time.Duration
+	minRefetchInterval time.Duration
+	now                func() time.Time
+	options            []Option
+
+	fetch       sync.Mutex
+	mu          sync.RWMutex
+	keys        map[string]signingKey
+	fetched     time.Time
+	lastAttempt time.Time
+}
+
+// JWKSOption defines a setter callback type to set an underlying JWKS option
+type JWKSOption func(j *JWKS)
+
+// WithRefreshInterval sets how often the cached keys are refreshed
+// The default interval is one hour
+func WithRefreshInterval(d time.Duration) JWKSOption {
+	return func(j *JWKS) { j.refreshInterval = d }
+}
+
+// WithMinRefetchInterval sets the minimum time between two fetches
+// triggered by stale keys or by tokens signed with unknown keys
+// The default interval is one minute
+func WithMinRefetchInterval(d time.Duration) JWKSOption {
+	return func(j *JWKS) { j.minRefetchInterval = d }
+}
+
+// WithJWTOptions sets the options used for validating the token claims
+func WithJWTOptions(opts ...Option) JWKSOption {
+	return func(j *JWKS) { j.options = append(j.options, opts...) }
+}
+
+// NewJWKS returns a token validator that uses the json web key set fetched
+// from source, NewJWKS fails if the key set can't be fetched or parsed
+func NewJWKS(source KeySetSource, opts ...JWKSOption) (*JWKS, error) {
+	j := &JWKS{
+		source:             source,
+		refreshInterval:    time.Hour,
+		minRefetchInterval: time.Minute,
+		now:                time.Now,
+	}
+	for _, setter := range opts {
+		setter(j)
+	}
+	j.JWT = newJWT(j.Keyfunc, jwksMethods, j.options...)
+	if err := j.Refresh(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Refresh fetches and parses the json web key set replacing all cached keys
+func (j *JWKS) Refresh() error {
+	j.fetch.Lock()
+	defer j.fetch.Unlock()
+	return j.refresh()
+}
+
+func (j *JWKS) refresh() error {
+	now := j.now()
+	j.mu.Lock()
+	j.lastAttempt = now
+	j.mu.Unlock()
+
+	b, err := j.source.Fetch()
+	if err != nil {
+		return err
+	}
+	keys, err := parseKeySet(b)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	changed := !sameKeys(j.keys, keys)
+	j.keys = keys
+	j.fetched = now
+	j.mu.Unlock()
+	if changed {
+		// the cached tokens could be signed by keys that were removed
+		j.cache.purge()
+	}
+	return nil
+}
+
+// sameKeys checks if both key sets hold the same keys
+func sameKeys(a, b map[string]signingKey) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for kid, k := range a {
+		other, ok := b[kid]
+		if !ok || k.alg != other.alg {
+			return false
+		}
+		key, ok := k.key.(interface{ Equal(crypto.PublicKey) bool })
+		if !ok || !key.Equal(other.key) {
+			return false
+		}
+	}
+	return true
+}
+
+func (j *JWKS) key(kid string) (signingKey, bool, bool) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	key, ok := j.keys[kid]
+	if !ok && kid == "" && len(j.keys) == 1 {
+		for _, k := range j.keys {
+			key, ok = k, true
+		}
+	}
+	stale := j.now().Sub(j.fetched) >= j.refreshInterval
+	return key, ok, stale
+}
+
+// Keyfunc returns the key used for verifying the token based on it's "kid" header
+// If the token has no "kid" header, the key set must contain only one key
+// The key must be of the type used by the token signing method and, if the
+// key declares an "alg", the token must be signed with that algorithm
+func (j *JWKS) Keyfunc(t *jwt.Token) (interface{}, error) {
+	key, err := j.lookup(t)
+	if err != nil {
+		return nil, err
+	}
+	if !compatible(t.Method, key.key) || (key.alg != "" && key.alg != t.Method.Alg()) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidAlgorithm, t.Method.Alg())
+	}
+	return key.key, nil
+}
+
+// compatible checks if the key can be used by the signing method
+func compatible(method jwt.SigningMethod, key interface{}) bool {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		switch method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+			return true
+		}
+	case *ecdsa.PublicKey:
+		return method.Alg() == ecdsaMethod(k)
+	case ed25519.PublicKey:
+		return method == SigningMethodEdDSA
+	}
+	return false
+}
+
+func (j *JWKS) lookup(t *jwt.Token) (signingKey, error) {
+	kid, _ := t.Header["kid"].(string)
+	key, ok, stale := j.key(kid)
+	if ok && !stale {
+		return key, nil
+	}
+	if j.limited() {
+		return j.cached(kid)
+	}
+
+	if ok {
+		// the stale keys are served while some other request refreshes them
+		if !j.fetch.TryLock() {
+			return key, nil
+		}
+	} else {
+		j.fetch.Lock()
+	}
+	// some other request could have refreshed the keys in the meantime
+	if key, ok, stale = j.key(kid); (ok && !stale) || j.limited() {
+		j.fetch.Unlock()
+		return j.cached(kid)
+	}
+	// on failure keep using the cached keys
+	_ = j.refresh()
+	j.fetch.Unlock()
+	return j.cached(kid)
+}
+
+// limited reports if the last fetch attempt was too recent for fetching again
+func (j *JWKS) limited() bool {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.now().Sub(j.lastAttempt) < j.minRefetchInterval
+}
+
+// cached returns the cached key even if it's stale
+func (j *JWKS) cached(kid string) (signingKey, error) {
+	if key, ok, _ := j.key(kid); ok {
+		return key, nil
+	}
+	return signingKey{}, ErrUnknownKey
+}
+
+// signingKey holds a public key of the key set and the
+// algorithm it's restricted to, if the key declares one
+type signingKey struct {
+	key interface{}
+	alg string
+}
+
+// jsonWebKey holds the members of a json web key
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseKeySet parses the json web key set returning all signing keys
+// keyed by their "kid", RSA, EC and Ed25519 keys are supported
+// Keys of other types, on other curves, that are not used for signing or
+// whose "alg" is not a supported signing algorithm are ignored
+// A malformed key of a supported type or two signing keys with the same
+// "kid" fail the whole set
+func ParseKeySet(b []byte) (map[string]interface{}, error) {
+	set, err := parseKeySet(b)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]interface{}, len(set))
+	for kid, k := range set {
+		keys[kid] = k.key
+	}
+	return keys, nil
+}
+
+func parseKeySet(b []byte) (map[string]signingKey, error) {
+	set := struct {
+		Keys []jsonWebKey `json:"keys"`
+	}{}
+	if err := json.Unmarshal(b, &set); err != nil {
+		return nil, fmt.Errorf("cannot parse the json web key set: %w", err)
+	}
+
+	keys := make(map[string]signingKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if k.Alg != "" && !supported(k.Alg) {
+			// e.g. RSA-OAEP keys are used only for encryption
+			continue
+		}
+		key, err := k.key()
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse the json web key %q: %w", k.Kid, err)
+		}
+		if key == nil {
+			continue
+		}
+		if k.Alg != "" && !compatible(jwt.GetSigningMethod(k.Alg), key) {
+			return nil, fmt.Errorf("cannot parse the json web key %q: %w: %s", k.Kid, ErrInvalidAlgorithm, k.Alg)
+		}
+		if _, ok := keys[k.Kid]; ok {
+			return nil, fmt.Errorf("cannot parse the json web key set: duplicate key id %q", k.Kid)
+		}
+		keys[k.Kid] = signingKey{key, k.Alg}
+	}
+	return keys, nil
+}
+
+// supported checks if alg is a signing algorithm of the key set
+func supported(alg string) bool {
+	for _, m := range jwksMethods {
+		if m == alg {
+			return true
+		}
+	}
+	return false
+}
+
+func decode(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func (k jsonWebKey) key() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if n.Sign() == 0 || !e.IsInt64() || e.Int64() < 2 {
+			return nil, errors.New("invalid RSA key")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			// keys on other curves can't be used by the ES algorithms
+			return nil, nil
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("invalid EC key, the point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			// e.g. X25519 keys are used only for key agreement
+			return nil, nil
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,311 @@
+package token_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gojwt "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoenirvili/rester/permission"
+	"github.com/hoenirvili/rester/token"
+)
+
+func encode(n *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(n.Bytes())
+}
+
+func rsaJWK(kid string, key *rsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"n":   encode(key.N),
+		"e":   encode(big.NewInt(int64(key.E))),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kty": "EC",
+		"kid": kid,
+		"crv": "P-256",
+		"x":   encode(key.X),
+		"y":   encode(key.Y),
+	}
+}
+
+func keySet(r *require.Assertions, keys ...map[string]string) []byte {
+	b, err := json.Marshal(map[string]interface{}{"keys": keys})
+	r.NoError(err)
+	return b
+}
+
+func signed(r *require.Assertions, method gojwt.SigningMethod, kid string, key interface{}) *http.Request {
+	t := gojwt.NewWithClaims(method, gojwt.MapClaims{
+		"exp":         time.Now().Add(time.Hour).Unix(),
+		"permissions": int(permission.Basic),
+	})
+	if kid != "" {
+		t.Header["kid"] = kid
+	}
+	str, err := t.SignedString(key)
+	r.NoError(err)
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	r.NoError(err)
+	req.Header.Set("Authorization", "Bearer "+str)
+	return req
+}
+
+func TestJWKS(t *testing.T) {
+	require := require.New(t)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(err)
+
+	set := keySet(require, rsaJWK("rsa", rsaKey), ecJWK("ec", ecKey))
+	jwks, err := token.NewJWKS(token.KeySetBytes(set))
+	require.NoError(err)
+
+	_, err = jwks.Verify(signed(require, gojwt.SigningMethodRS256, "rsa", rsaKey))
+	require.NoError(err)
+	_, err = jwks.Verify(signed(require, gojwt.SigningMethodES256, "ec", ecKey))
+	require.NoError(err)
+
+	_, err = jwks.Verify(signed(require, gojwt.SigningMethodRS256, "other", rsaKey))
+	require.Error(err)
+	require.True(errors.Is(err, token.ErrUnknownKey))
+
+	// a token without kid is rejected when the set has more than one key
+	_, err = jwks.Verify(signed(require, gojwt.SigningMethodRS256, "", rsaKey))
+	require.Error(err)
+}
+
+func TestJWKSFile(t *testing.T) {
+	require := require.New(t)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(os.WriteFile(path, keySet(require, rsaJWK("1", key)), 0600))
+
+	jwks, err := token.NewJWKS(token.KeySetFile(path))
+	require.NoError(err)
+	_, err = jwks.Verify(signed(require, gojwt.SigningMethodRS256, "", key))
+	require.NoError(err)
+
+	_, err = token.NewJWKS(token.KeySetFile(filepath.Join(t.TempDir(), "missing")))
+	require.Error(err)
+}
+
+func TestJWKSRotation(t *testing.T) {
+	require := require.New(t)
+	old, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(err)
+	rotated, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(err)
+
+	var (
+		fetches int32
+		current atomic.Value
+	)
+	current.Store(keySet(require, rsaJWK("old", old)))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		w.Write(current.Load().([]byte))
+	}))
+	defer srv.Close()
+
+	jwks, err := token.NewJWKS(token.KeySetURL(srv.URL, srv.Client()),
+		token.WithMinRefetchInterval(0))
+	require.NoError(err)
+	require.EqualValues(1, atomic.LoadInt32(&fetches))
+
+	_, err = jwks.Verify(signed(require, gojwt.SigningMethodRS256, "old", old))
+	require.NoError(err)
+	require.EqualValues(1, atomic.LoadInt32(&fetches))
+
+	current.Store(keySet(require, rsaJWK("new", rotated)))
+	_, err = jwks.Verify(signed(require, gojwt.SigningMethodRS256, "new", rotated))
+	require.NoError(err)
+	require.EqualValues(2, atomic.LoadInt32(&fetches))
+
+	_, err = jwks.Verify(signed(require, gojwt.SigningMethodRS256, "old", old))
+	require.True(errors.Is(err, token.ErrUnknownKey))
+}
+
+func TestJWKSRefetchRateLimit(t *testing.T) {
+	require := require.New(t)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(err)
+
+	var fetches int32
+	set := keySet(require, rsaJWK("1", key))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		w.Write(set)
+	}))
+	defer srv.Close()
+
+	jwks, err := token.NewJWKS(token.KeySetURL(srv.URL, nil),
+		token.WithMinRefetchInterval(time.Hour))
+	require.NoError(err)
+
+	for i := 0; i < 5; i++ {
+		_, err = jwks.Verify(signed(require, gojwt.SigningMethodRS256, "unknown", key))
+		require.True(errors.Is(err, token.ErrUnknownKey))
+	}
+	require.EqualValues(1, atomic.LoadInt32(&fetches))
+
+	require.NoError(jwks.Refresh())
+	require.EqualValues(2, atomic.LoadInt32(&fetches))
+}
+
+func TestJWKSRefreshInterval(t *testing.T) {
+	require := require.New(t)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(err)
+
+	var fetches int32
+	set := keySet(require, rsaJWK("1", key))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		w.Write(set)
+	}))
+	defer srv.Close()
+
+	jwks, err := token.NewJWKS(token.KeySetURL(srv.URL, nil),
+		token.WithRefreshInterval(0), token.WithMinRefetchInterval(0))
+	require.NoError(err)
+
+	_, err = jwks.Verify(signed(require, gojwt.SigningMethodRS256, "1", key))
+	require.NoError(err)
+	require.EqualValues(2, atomic.LoadInt32(&fetches))
+}
+
+func TestJWKSStaleRateLimit(t *testing.T) {
+	require := require.New(t)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(err)
+
+	var fetches int32
+	set := keySet(require, rsaJWK("1", key))
+	source := token.KeySetFunc(func() ([]byte, error) {
+		if atomic.AddInt32(&fetches, 1) > 1 {
+			return nil, errors.New("key set is down")
+		}
+		return set, nil
+	})
+	jwks, err := token.NewJWKS(source,
+		token.WithRefreshInterval(0), token.WithMinRefetchInterval(time.Hour))
+	require.NoError(err)
+
+	for i := 0; i < 10; i++ {
+		_, err = jwks.Verify(signed(require, gojwt.SigningMethodRS256, "1", key))
+		require.NoError(err)
+	}
+	require.EqualValues(1, atomic.LoadInt32(&fetches))
+}
+
+func TestParseKeySet(t *testing.T) {
+	require := require.New(t)
+	keys, err := token.ParseKeySet([]byte(`{"keys":[
+		{"kty":"oct","kid":"sym","k":"c2VjcmV0"},
+		{"kty":"RSA","kid":"enc","use":"enc","n":"AQAB","e":"AQAB"},
+		{"kty":"EC","kid":"k1","crv":"secp256k1","x":"AQ","y":"AQ"},
+		{"kty":"OKP","kid":"ecdh","crv":"X25519","x":"AQ"}
+	]}`))
+	require.NoError(err)
+	require.Empty(keys)
+
+	_, err = token.ParseKeySet([]byte(`{"keys":[{"kty":"EC","kid":"1","crv":"P-256","x":"AQ","y":"AQ"}]}`))
+	require.Error(err)
+
+	keys, err = token.ParseKeySet([]byte(`{"keys":[{"kty":"RSA","kid":"enc","alg":"RSA-OAEP","n":"AQAB","e":"AQAB"}]}`))
+	require.NoError(err)
+	require.Empty(keys)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(err)
+	_, err = token.ParseKeySet(keySet(require, rsaJWK("1", key), rsaJWK("1", key)))
+	require.Error(err)
+	require.Contains(err.Error(), `duplicate key id "1"`)
+
+	k := rsaJWK("1", key)
+	k["alg"] = "ES256"
+	_, err = token.ParseKeySet(keySet(require, k))
+	require.True(errors.Is(err, token.ErrInvalidAlgorithm))
+
+	_, err = token.ParseKeySet([]byte(`not json`))
+	require.Error(err)
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	_, err = token.NewJWKS(token.KeySetURL(srv.URL, nil))
+	require.Error(err)
+}
+
+func TestJWKSAlgorithms(t *testing.T) {
+	require := require.New(t)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(err)
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(err)
+
+	ed := map[string]string{
+		"kty": "OKP",
+		"kid": "ed",
+		"crv": "Ed25519",
+		"x":   base64.RawURLEncoding.EncodeToString(edPublic),
+	}
+	set := keySet(require, rsaJWK("rsa", rsaKey), ecJWK("ec", ecKey), ed)
+	jwks, err := token.NewJWKS(token.KeySetBytes(set))
+	require.NoError(err)
+
+	_, err = jwks.Verify(signed(require, token.SigningMethodEdDSA, "ed", edPrivate))
+	require.NoError(err)
+
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(err)
+	_, err = jwks.Verify(signed(require, gojwt.SigningMethodES384, "ec", p384))
+	require.True(errors.Is(err, token.ErrInvalidAlgorithm))
+	_, err = jwks.Verify(signed(require, token.SigningMethodEdDSA, "rsa", edPrivate))
+	require.True(errors.Is(err, token.ErrInvalidAlgorithm))
+	_, err = jwks.Verify(signed(require, gojwt.SigningMethodHS256, "rsa", []byte("secret")))
+	require.True(errors.Is(err, token.ErrInvalidAlgorithm))
+}
+
+func TestJWKSKeyAlgorithm(t *testing.T) {
+	require := require.New(t)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(err)
+	k := rsaJWK("1", key)
+	k["alg"] = "RS256"
+	jwks, err := token.NewJWKS(token.KeySetBytes(keySet(require, k)))
+	require.NoError(err)
+
+	_, err = jwks.Verify(signed(require, gojwt.SigningMethodRS256, "1", key))
+	require.NoError(err)
+	// the key can only verify tokens signed with the algorithm it declares
+	_, err = jwks.Verify(signed(require, gojwt.SigningMethodRS512, "1", key))
+	require.True(errors.Is(err, token.ErrInvalidAlgorithm))
+	_, err = jwks.Verify(signed(require, gojwt.SigningMethodPS256, "1", key))
+	require.True(errors.Is(err, token.ErrInvalidAlgorithm))
+}
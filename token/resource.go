@@ -0,0 +1,154 @@
+package token
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/hoenirvili/rester/permission"
+	"github.com/hoenirvili/rester/request"
+	"github.com/hoenirvili/rester/resource"
+	"github.com/hoenirvili/rester/response"
+	"github.com/hoenirvili/rester/route"
+)
+
+// Authenticator checks the credentials found in the request
+// returning the subject, the permissions and the roles of the access token
+type Authenticator func(req request.Request) (subject string, p permission.Permissions, roles []permission.Role, err error)
+
+// Resource exposes the token issuing routes
+// Mounted with rester.Resource("/token", token.NewResource(issuer, auth))
+// it responds to POST /token and POST /token/refresh
+type Resource struct {
+	issuer *Issuer
+	auth   Authenticator
+}
+
+// NewResource returns a new resource that issues tokens
+// to the clients authenticated by auth
+func NewResource(issuer *Issuer, auth Authenticator) *Resource {
+	return &Resource{issuer, auth}
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" form:"refresh_token" validate:"required"`
+}
+
+// tokens returns the pair of tokens that should never be cached
+func tokens(pair *Pair) resource.Response {
+	resp := response.Payload(pair)
+	resp.Headers = http.Header{
+		"Cache-Control": []string{"no-store"},
+		"Pragma":        []string{"no-cache"},
+	}
+	return resp
+}
+
+func (t *Resource) issue(req request.Request) resource.Response {
+	subject, p, roles, err := t.auth(req)
+	if err != nil {
+		return response.Unauthorized(err.Error())
+	}
+	pair, err := t.issuer.Issue(subject, p, roles...)
+	if err != nil {
+		return response.InternalError(err.Error())
+	}
+	return tokens(pair)
+}
+
+func (t *Resource) refresh(req request.Request) resource.Response {
+	body := req.Bound().(*refreshRequest)
+	pair, err := t.issuer.Refresh(body.RefreshToken)
+	switch {
+	case errors.Is(err, ErrInvalidRefreshToken), errors.Is(err, ErrRevoked):
+		return response.Unauthorized(err.Error())
+	case err != nil:
+		return response.InternalError(err.Error())
+	}
+	return tokens(pair)
+}
+
+// Routes returns the token issuing routes
+func (t *Resource) Routes() route.Routes {
+	return route.Routes{{
+		URL:     "/",
+		Method:  resource.Post,
+		Handler: t.issue,
+	}, {
+		URL:     "/refresh",
+		Method:  resource.Post,
+		Handler: t.refresh,
+		Body:    refreshRequest{},
+	}}
+}
+
+// RevocationResource exposes the admin routes used for revoking tokens
+// Mounted with rester.Resource("/revocations", token.NewRevocationResource(r, permission.Admin))
+// it responds to POST /revocations/tokens and POST /revocations/subjects
+type RevocationResource struct {
+	revoker Revoker
+	allow   permission.Permissions
+}
+
+// NewRevocationResource returns a new resource that revokes tokens
+// The routes can be accessed only by the given permissions, if allow
+// is zero only permission.Admin can access them
+// It panics if allow would make the routes accessible by anyone
+func NewRevocationResource(r Revoker, allow permission.Permissions) *RevocationResource {
+	if allow == 0 {
+		allow = permission.Admin
+	}
+	if allow&permission.Anonymous != 0 {
+		panic("token: the revocation routes cannot be anonymous")
+	}
+	return &RevocationResource{r, allow}
+}
+
+type revokeToken struct {
+	// ID is the "jti" claim of the revoked token
+	ID string `json:"jti" form:"jti" validate:"required"`
+	// Expires is the "exp" claim of the revoked token, if known
+	Expires time.Time `json:"expires_at"`
+}
+
+type revokeSubject struct {
+	Subject string `json:"subject" form:"subject" validate:"required"`
+	// Before revokes the tokens issued before it, now if not set
+	Before time.Time `json:"before"`
+}
+
+func (t *RevocationResource) token(req request.Request) resource.Response {
+	body := req.Bound().(*revokeToken)
+	if err := t.revoker.RevokeID(body.ID, body.Expires); err != nil {
+		return response.InternalError(err.Error())
+	}
+	return response.NoContent()
+}
+
+func (t *RevocationResource) subject(req request.Request) resource.Response {
+	body := req.Bound().(*revokeSubject)
+	if body.Before.IsZero() {
+		body.Before = time.Now()
+	}
+	if err := t.revoker.RevokeSubject(body.Subject, body.Before); err != nil {
+		return response.InternalError(err.Error())
+	}
+	return response.NoContent()
+}
+
+// Routes returns the revocation routes
+func (t *RevocationResource) Routes() route.Routes {
+	return route.Routes{{
+		URL:     "/tokens",
+		Method:  resource.Post,
+		Handler: t.token,
+		Body:    revokeToken{},
+		Allow:   t.allow,
+	}, {
+		URL:     "/subjects",
+		Method:  resource.Post,
+		Handler: t.subject,
+		Body:    revokeSubject{},
+		Allow:   t.allow,
+	}}
+}
@@ -0,0 +1,71 @@
+package token_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoenirvili/rester"
+	"github.com/hoenirvili/rester/permission"
+	"github.com/hoenirvili/rester/request"
+	"github.com/hoenirvili/rester/token"
+)
+
+func post(r *require.Assertions, srv *httptest.Server, url, body string) (*http.Response, token.Pair) {
+	resp, err := http.Post(srv.URL+url, "application/json", strings.NewReader(body))
+	r.NoError(err)
+	defer resp.Body.Close()
+	var pair token.Pair
+	if resp.StatusCode == http.StatusOK {
+		r.NoError(json.NewDecoder(resp.Body).Decode(&pair))
+	}
+	return resp, pair
+}
+
+func TestResource(t *testing.T) {
+	require := require.New(t)
+	auth := func(req request.Request) (string, permission.Permissions, []permission.Role, error) {
+		var credentials struct {
+			User     string `json:"user"`
+			Password string `json:"password"`
+		}
+		if err := req.JSON(&credentials); err != nil {
+			return "", 0, nil, err
+		}
+		if credentials.Password != "secret" {
+			return "", 0, nil, errors.New("invalid credentials")
+		}
+		return credentials.User, permission.Basic, []permission.Role{support}, nil
+	}
+
+	r := rester.New()
+	r.Resource("/token", token.NewResource(issuer(require), auth))
+	require.NoError(r.Build())
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	resp, _ := post(require, srv, "/token", `{"user":"alice","password":"wrong"}`)
+	require.Equal(http.StatusUnauthorized, resp.StatusCode)
+
+	resp, pair := post(require, srv, "/token", `{"user":"alice","password":"secret"}`)
+	require.Equal(http.StatusOK, resp.StatusCode)
+	require.Equal("no-store", resp.Header.Get("Cache-Control"))
+	claims, err := jwt(require).Verify(bearer(pair.AccessToken))
+	require.NoError(err)
+	require.Equal([]interface{}{"token-support"}, claims["roles"])
+
+	resp, next := post(require, srv, "/token/refresh", `{"refresh_token":"`+pair.RefreshToken+`"}`)
+	require.Equal(http.StatusOK, resp.StatusCode)
+	require.NotEmpty(next.AccessToken)
+
+	resp, _ = post(require, srv, "/token/refresh", `{"refresh_token":"`+pair.RefreshToken+`"}`)
+	require.Equal(http.StatusUnauthorized, resp.StatusCode)
+
+	resp, _ = post(require, srv, "/token/refresh", `{}`)
+	require.Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+}
@@ -0,0 +1,146 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrRevoked returned when the token was revoked before it's expiration
+var ErrRevoked = errors.New("token has been revoked")
+
+// Revocation reports the revoked tokens
+// Implement this to check the revocations kept in an external store
+type Revocation interface {
+	// Revoked reports if the token identified by the "jti" claim is revoked
+	// or if the tokens of the subject issued at issuedAt are revoked
+	// jti and subject are empty and issuedAt is zero if the token has no such claims
+	Revoked(jti, subject string, issuedAt time.Time) (bool, error)
+}
+
+// Revoker is a Revocation that can also revoke tokens
+type Revoker interface {
+	Revocation
+	// RevokeID revokes the token identified by the "jti" claim
+	// The revocation can be forgotten after expires
+	RevokeID(jti string, expires time.Time) error
+	// RevokeSubject revokes all tokens of the subject issued at or before the given time
+	RevokeSubject(subject string, before time.Time) error
+}
+
+// checkRevoked checks the claims of the verified token
+func (j *JWT) checkRevoked(claims *Claims) error {
+	if j.revoked == nil {
+		return nil
+	}
+	jti, _ := claims.mapClaims["jti"].(string)
+	sub, _ := claims.mapClaims["sub"].(string)
+	var issuedAt time.Time
+	if iat, ok := claims.number("iat"); ok {
+		issuedAt = time.Unix(int64(iat), 0)
+	}
+	revoked, err := j.revoked.Revoked(jti, sub, issuedAt)
+	if err != nil {
+		return fmt.Errorf("cannot check if the token is revoked: %w", err)
+	}
+	if revoked {
+		return ErrRevoked
+	}
+	return nil
+}
+
+// Denylist is an in-memory Revoker
+// Revoked ids are kept until their expiration and revoked
+// subjects are kept for the ttl given to NewDenylist
+type Denylist struct {
+	ttl      time.Duration
+	mu       sync.Mutex
+	ids      map[string]time.Time
+	subjects map[string]cutoff
+}
+
+// cutoff holds the time before which the subject tokens are revoked
+type cutoff struct {
+	before  time.Time
+	expires time.Time
+}
+
+// NewDenylist returns a new empty Denylist
+// The ttl should be at least the lifetime of the access tokens
+func NewDenylist(ttl time.Duration) *Denylist {
+	return &Denylist{
+		ttl:      ttl,
+		ids:      make(map[string]time.Time),
+		subjects: make(map[string]cutoff),
+	}
+}
+
+// prune removes all expired revocations
+func (d *Denylist) prune(now time.Time) {
+	for jti, expires := range d.ids {
+		if !now.Before(expires) {
+			delete(d.ids, jti)
+		}
+	}
+	for subject, c := range d.subjects {
+		if !now.Before(c.expires) {
+			delete(d.subjects, subject)
+		}
+	}
+}
+
+// RevokeID revokes the token identified by jti until expires
+// If expires is zero the revocation is kept for the denylist ttl
+func (d *Denylist) RevokeID(jti string, expires time.Time) error {
+	if jti == "" {
+		return errors.New("cannot revoke a token without jti")
+	}
+	now := time.Now()
+	if expires.IsZero() {
+		expires = now.Add(d.ttl)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.prune(now)
+	d.ids[jti] = expires
+	return nil
+}
+
+// RevokeSubject revokes all tokens of the subject issued at or before the given time
+// The "iat" claim has a precision of seconds so the access tokens issued in
+// the same second as before are also revoked
+func (d *Denylist) RevokeSubject(subject string, before time.Time) error {
+	if subject == "" {
+		return errors.New("cannot revoke the tokens of an empty subject")
+	}
+	now := time.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.prune(now)
+	if c, ok := d.subjects[subject]; ok && c.before.After(before) {
+		before = c.before
+	}
+	d.subjects[subject] = cutoff{before, now.Add(d.ttl)}
+	return nil
+}
+
+// Revoked reports if the token is revoked
+// Tokens without "iat" of a revoked subject are always revoked
+// issuedAt is compared with the exact revocation time, an "iat" claim is
+// the start of its second so it's revoked unless it's after that time
+func (d *Denylist) Revoked(jti, subject string, issuedAt time.Time) (bool, error) {
+	now := time.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if expires, ok := d.ids[jti]; ok && jti != "" && now.Before(expires) {
+		return true, nil
+	}
+	c, ok := d.subjects[subject]
+	if !ok || subject == "" || !now.Before(c.expires) {
+		return false, nil
+	}
+	return issuedAt.IsZero() || !issuedAt.After(c.before), nil
+}
+
+var _ Revoker = (*Denylist)(nil)
@@ -0,0 +1,190 @@
+package token_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gojwt "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoenirvili/rester"
+	"github.com/hoenirvili/rester/permission"
+	"github.com/hoenirvili/rester/token"
+)
+
+func TestDenylist(t *testing.T) {
+	require := require.New(t)
+	d := token.NewDenylist(time.Hour)
+	now := time.Now()
+
+	require.NoError(d.RevokeID("1", time.Time{}))
+	require.NoError(d.RevokeID("2", now.Add(-time.Second)))
+	require.Error(d.RevokeID("", time.Time{}))
+
+	revoked, err := d.Revoked("1", "alice", now)
+	require.NoError(err)
+	require.True(revoked)
+	revoked, err = d.Revoked("2", "alice", now)
+	require.NoError(err)
+	require.False(revoked)
+
+	require.NoError(d.RevokeSubject("alice", now))
+	require.Error(d.RevokeSubject("", now))
+	revoked, err = d.Revoked("3", "alice", now.Add(-time.Minute))
+	require.NoError(err)
+	require.True(revoked)
+	revoked, err = d.Revoked("3", "alice", time.Time{})
+	require.NoError(err)
+	require.True(revoked)
+	revoked, err = d.Revoked("3", "alice", now.Add(time.Minute))
+	require.NoError(err)
+	require.False(revoked)
+	revoked, err = d.Revoked("3", "bob", now.Add(-time.Minute))
+	require.NoError(err)
+	require.False(revoked)
+
+	// an earlier cutoff does not undo a later one
+	require.NoError(d.RevokeSubject("alice", now.Add(-time.Hour)))
+	revoked, err = d.Revoked("", "alice", now.Add(-time.Minute))
+	require.NoError(err)
+	require.True(revoked)
+}
+
+func TestRevokeSubjectThenIssue(t *testing.T) {
+	require := require.New(t)
+	public, err := gojwt.ParseRSAPublicKeyFromPEM(pub)
+	require.NoError(err)
+	d := token.NewDenylist(time.Hour)
+	jwt := token.NewJWT(public, token.WithRevocation(d))
+
+	// the "iat" claim can't tell if the token was issued
+	// before or after the revocation in the same second
+	require.NoError(d.RevokeSubject("alice", time.Now()))
+	access, err := issuer(require).Access("alice", permission.Basic)
+	require.NoError(err)
+	_, err = jwt.Verify(bearer(access))
+	require.True(errors.Is(err, token.ErrRevoked))
+
+	require.NoError(d.RevokeSubject("bob", time.Now().Add(-2*time.Second)))
+	access, err = issuer(require).Access("bob", permission.Basic)
+	require.NoError(err)
+	_, err = jwt.Verify(bearer(access))
+	require.NoError(err)
+}
+
+func TestRevokeSubjectRefresh(t *testing.T) {
+	require := require.New(t)
+	d := token.NewDenylist(time.Hour)
+	i := issuer(require, token.WithRefreshRevocation(d))
+	pair, err := i.Issue("alice", permission.Basic)
+	require.NoError(err)
+
+	// sessions authenticated just before the revocation can't be refreshed
+	require.NoError(d.RevokeSubject("alice", time.Now()))
+	_, err = i.Refresh(pair.RefreshToken)
+	require.True(errors.Is(err, token.ErrRevoked))
+}
+
+type failingRevocation struct{}
+
+func (failingRevocation) Revoked(string, string, time.Time) (bool, error) {
+	return false, errors.New("store is down")
+}
+
+func TestVerifyRevoked(t *testing.T) {
+	require := require.New(t)
+	public, err := gojwt.ParseRSAPublicKeyFromPEM(pub)
+	require.NoError(err)
+	d := token.NewDenylist(time.Hour)
+	jwt := token.NewJWT(public, token.WithRevocation(d))
+
+	i := issuer(require)
+	first, err := i.Access("alice", permission.Basic)
+	require.NoError(err)
+	second, err := i.Access("bob", permission.Basic)
+	require.NoError(err)
+
+	claims, err := jwt.Verify(bearer(first))
+	require.NoError(err)
+	require.NoError(d.RevokeID(claims["jti"].(string), time.Time{}))
+	_, err = jwt.Verify(bearer(first))
+	require.True(errors.Is(err, token.ErrRevoked))
+
+	_, err = jwt.Verify(bearer(second))
+	require.NoError(err)
+	require.NoError(d.RevokeSubject("bob", time.Now().Add(time.Second)))
+	_, err = jwt.Verify(bearer(second))
+	require.True(errors.Is(err, token.ErrRevoked))
+
+	_, err = token.NewJWT(public, token.WithRevocation(failingRevocation{})).Verify(bearer(second))
+	require.Error(err)
+	require.False(errors.Is(err, token.ErrRevoked))
+}
+
+func TestIssuerRefreshRevoked(t *testing.T) {
+	require := require.New(t)
+	d := token.NewDenylist(time.Hour)
+	i := issuer(require, token.WithRefreshRevocation(d))
+	pair, err := i.Issue("alice", permission.Basic)
+	require.NoError(err)
+	pair, err = i.Refresh(pair.RefreshToken)
+	require.NoError(err)
+
+	require.NoError(d.RevokeSubject("alice", time.Now().Add(time.Second)))
+	_, err = i.Refresh(pair.RefreshToken)
+	require.True(errors.Is(err, token.ErrRevoked))
+}
+
+func TestRevocationResource(t *testing.T) {
+	require := require.New(t)
+	public, err := gojwt.ParseRSAPublicKeyFromPEM(pub)
+	require.NoError(err)
+	d := token.NewDenylist(time.Hour)
+
+	r := rester.New(rester.WithTokenValidator(token.NewJWT(public, token.WithRevocation(d))))
+	r.Resource("/revocations", token.NewRevocationResource(d, permission.Admin))
+	require.NoError(r.Build())
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	i := issuer(require)
+	admin, err := i.Access("root", permission.Admin)
+	require.NoError(err)
+	basic, err := i.Access("alice", permission.Basic)
+	require.NoError(err)
+
+	do := func(access, url, body string) int {
+		req, err := http.NewRequest(http.MethodPost, srv.URL+url, strings.NewReader(body))
+		require.NoError(err)
+		req.Header.Set("Authorization", "Bearer "+access)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	require.Equal(http.StatusForbidden, do(basic, "/revocations/subjects", `{"subject":"root"}`))
+	require.Equal(http.StatusUnprocessableEntity, do(admin, "/revocations/tokens", `{}`))
+	require.Equal(http.StatusNoContent, do(admin, "/revocations/subjects", `{"subject":"alice","before":"2100-01-01T00:00:00Z"}`))
+	require.Equal(http.StatusUnauthorized, do(basic, "/revocations/subjects", `{"subject":"root"}`))
+
+	claims, err := token.NewJWT(public).Verify(bearer(admin))
+	require.NoError(err)
+	require.Equal(http.StatusNoContent, do(admin, "/revocations/tokens", `{"jti":"`+claims["jti"].(string)+`"}`))
+	require.Equal(http.StatusUnauthorized, do(admin, "/revocations/tokens", `{"jti":"other"}`))
+}
+
+func TestRevocationResourceAllow(t *testing.T) {
+	require := require.New(t)
+	d := token.NewDenylist(time.Hour)
+	for _, route := range token.NewRevocationResource(d, 0).Routes() {
+		require.Equal(permission.Admin, route.Allow)
+	}
+	require.Panics(func() { token.NewRevocationResource(d, permission.Anonymous) })
+	require.Panics(func() { token.NewRevocationResource(d, permission.Anonymous|permission.Admin) })
+}
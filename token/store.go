@@ -0,0 +1,46 @@
+package token
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryStore is an in-memory RefreshStore
+// Expired sessions are removed when new sessions are saved
+type MemoryStore struct {
+	mu       sync.Mutex
+	sessions map[string]Session
+}
+
+// NewMemoryStore returns a new empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: make(map[string]Session)}
+}
+
+// Save stores the session under the given hash
+func (m *MemoryStore) Save(hash string, s Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for key, session := range m.sessions {
+		if !now.Before(session.Expires) {
+			delete(m.sessions, key)
+		}
+	}
+	m.sessions[hash] = s
+	return nil
+}
+
+// Take returns and removes the session stored under the given hash
+func (m *MemoryStore) Take(hash string) (Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[hash]
+	if !ok {
+		return Session{}, ErrInvalidRefreshToken
+	}
+	delete(m.sessions, hash)
+	return s, nil
+}
+
+var _ RefreshStore = (*MemoryStore)(nil)
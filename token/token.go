@@ -2,37 +2,250 @@
 package token
 
 import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/dgrijalva/jwt-go/request"
 )
 
+// ErrInvalidAlgorithm returned when the token is signed
+// using an algorithm that is not accepted by the validator
+var ErrInvalidAlgorithm = errors.New("signing method is not accepted")
+
+// ErrNoKey returned when the validator has no key to verify the token
+var ErrNoKey = errors.New("no key found to verify the token")
+
+var (
+	rsaMethods   = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}
+	hmacMethods  = []string{"HS256", "HS384", "HS512"}
+	ecdsaMethods = []string{"ES256", "ES384", "ES512"}
+	eddsaMethods = []string{"EdDSA"}
+)
+
 type JWT struct {
-	extractor request.Extractor
-	keyFunc   jwt.Keyfunc
-	options   []request.ParseFromRequestOption
-	claims    *Claims
+	extractors []Extractor
+	keyFunc    jwt.Keyfunc
+	rules      *rules
+	cache      *cache
+	methods    []string
+	revoked    Revocation
+}
+
+// Option defines a setter callback type to set an underlying JWT option
+type Option func(j *JWT)
+
+// WithIssuer accepts only tokens that have one of the given "iss" claims
+func WithIssuer(issuers ...string) Option {
+	return func(j *JWT) { j.rules.issuers = issuers }
+}
+
+// WithAudience accepts only tokens that have at least one of
+// the given audiences in the "aud" claim
+func WithAudience(audiences ...string) Option {
+	return func(j *JWT) { j.rules.audiences = audiences }
+}
+
+// WithLeeway sets the clock skew tolerated when validating
+// the "exp", "nbf" and "iat" claims
+func WithLeeway(d time.Duration) Option {
+	return func(j *JWT) { j.rules.leeway = d }
+}
+
+// WithMaxAge accepts only tokens that were issued in the given duration
+// based on the "iat" claim, tokens without "iat" will be rejected
+func WithMaxAge(d time.Duration) Option {
+	return func(j *JWT) { j.rules.maxAge = d }
+}
+
+// WithoutPermissionClaim accepts tokens that don't have the numeric
+// "permissions" claim, use this with rester.WithPermissionMapper
+// when the permissions are resolved from other claims
+func WithoutPermissionClaim() Option {
+	return func(j *JWT) { j.rules.optional = true }
+}
+
+// WithRevocation rejects the tokens reported as revoked by r
+func WithRevocation(r Revocation) Option {
+	return func(j *JWT) { j.revoked = r }
+}
+
+// WithAlgorithms restricts the accepted signing methods to the given ones
+// e.g. WithAlgorithms("RS256") will reject tokens signed with RS512
+// The algorithms can only narrow the ones accepted by the validator,
+// the constructor panics if none of them is accepted
+func WithAlgorithms(algs ...string) Option {
+	return func(j *JWT) {
+		var methods []string
+		for _, m := range j.methods {
+			for _, alg := range algs {
+				if m == alg {
+					methods = append(methods, m)
+					break
+				}
+			}
+		}
+		if len(methods) == 0 {
+			panic(fmt.Sprintf("token: none of the algorithms %q can be accepted", algs))
+		}
+		j.methods = methods
+	}
+}
+
+// NewJWT returns a token validator for tokens signed using
+// the RS256, RS384, RS512, PS256, PS384 or PS512 algorithms
+func NewJWT(key *rsa.PublicKey, opts ...Option) *JWT {
+	fn := func(t *jwt.Token) (interface{}, error) {
+		if key == nil {
+			return nil, ErrNoKey
+		}
+		return key, nil
+	}
+	return newJWT(fn, rsaMethods, opts...)
+}
+
+// MinHMACSecret is the minimum length in bytes of a HMAC secret
+// which is the output size of HS256 as required by RFC 7518
+const MinHMACSecret = 32
+
+// NewHMAC returns a token validator for tokens signed with the shared
+// secret using the HS256, HS384 or HS512 algorithms
+// Only the algorithms whose output size is not larger than the
+// secret are accepted, e.g. a 32 bytes secret accepts only HS256
+// If the secret is shorter than MinHMACSecret, NewHMAC panics
+func NewHMAC(secret []byte, opts ...Option) *JWT {
+	if len(secret) < MinHMACSecret {
+		panic(fmt.Sprintf("token: NewHMAC called with a secret shorter than %d bytes", MinHMACSecret))
+	}
+	secret = append([]byte(nil), secret...)
+	fn := func(t *jwt.Token) (interface{}, error) { return secret, nil }
+	var methods []string
+	for i, size := range []int{32, 48, 64} {
+		if len(secret) >= size {
+			methods = append(methods, hmacMethods[i])
+		}
+	}
+	return newJWT(fn, methods, opts...)
 }
 
-func NewJWT(key *rsa.PublicKey) *JWT {
-	claims := NewClaims()
-	opt := request.WithClaims(claims)
+// NewECDSA returns a token validator for tokens signed using the
+// ES256, ES384 or ES512 algorithm, only the algorithm matching
+// the curve of the key is accepted
+func NewECDSA(key *ecdsa.PublicKey, opts ...Option) *JWT {
+	fn := func(t *jwt.Token) (interface{}, error) {
+		if key == nil {
+			return nil, ErrNoKey
+		}
+		return key, nil
+	}
+	methods := ecdsaMethods
+	if key != nil && key.Curve != nil {
+		methods = []string{ecdsaMethod(key)}
+	}
+	return newJWT(fn, methods, opts...)
+}
+
+// NewEd25519 returns a token validator for tokens signed
+// using the EdDSA algorithm with an Ed25519 key
+func NewEd25519(key ed25519.PublicKey, opts ...Option) *JWT {
 	fn := func(t *jwt.Token) (interface{}, error) { return key, nil }
-	ext := request.AuthorizationHeaderExtractor
-	return &JWT{ext, fn, []request.ParseFromRequestOption{opt}, claims}
+	return newJWT(fn, eddsaMethods, opts...)
+}
+
+// ecdsaMethod returns the algorithm name that uses the curve of the key
+func ecdsaMethod(key *ecdsa.PublicKey) string {
+	switch key.Curve.Params().BitSize {
+	case 256:
+		return "ES256"
+	case 384:
+		return "ES384"
+	case 521:
+		return "ES512"
+	default:
+		return ""
+	}
+}
+
+func newJWT(fn jwt.Keyfunc, methods []string, opts ...Option) *JWT {
+	j := &JWT{
+		extractors: []Extractor{FromAuthorization()},
+		keyFunc:    fn,
+		rules:      &rules{now: time.Now},
+		methods:    methods,
+	}
+	for _, setter := range opts {
+		setter(j)
+	}
+	return j
 }
 
 func (j *JWT) Verify(r *http.Request) (map[string]interface{}, error) {
+	claims, _, err := j.VerifySource(r)
+	return claims, err
+}
+
+// VerifySource is like Verify but it also returns the
+// source of the extractor that found the token
+// Every call returns a new claims map so it's safe for concurrent use
+func (j *JWT) VerifySource(r *http.Request) (map[string]interface{}, string, error) {
+	token, source, err := j.extract(r)
+	if err != nil {
+		return nil, "", err
+	}
+	if claims, ok := j.cache.get(token); ok {
+		if err := j.revalidate(claims); err != nil {
+			return nil, "", err
+		}
+		return claims.copy(), source, nil
+	}
+
+	gen := j.cache.gen()
+	claims := &Claims{make(jwt.MapClaims), j.rules}
 	t, err := request.ParseFromRequest(r,
-		j.extractor, j.keyFunc, j.options...)
+		extracted(token), j.key, request.WithClaims(claims))
 	if err != nil {
-		return nil, err
+		// surface the errors returned by the key func
+		// so they can be checked with errors.Is
+		var ve *jwt.ValidationError
+		if errors.As(err, &ve) && ve.Inner != nil {
+			return nil, "", ve.Inner
+		}
+		return nil, "", err
 	}
 	if !t.Valid {
-		return nil, errors.New("jwt token is not valid")
+		return nil, "", errors.New("jwt token is not valid")
+	}
+	if err := j.checkRevoked(claims); err != nil {
+		return nil, "", err
+	}
+	j.cache.add(token, claims, gen)
+	return claims.copy(), source, nil
+}
+
+// revalidate checks the time based claims and the
+// revocation of a token that was already verified
+func (j *JWT) revalidate(claims *Claims) error {
+	if err := claims.Valid(); err != nil {
+		var ve *jwt.ValidationError
+		if errors.As(err, &ve) && ve.Inner != nil {
+			return ve.Inner
+		}
+		return err
+	}
+	return j.checkRevoked(claims)
+}
+
+// key checks that the token is signed using one of
+// the pinned algorithms before looking up the key
+func (j *JWT) key(t *jwt.Token) (interface{}, error) {
+	alg := t.Method.Alg()
+	if !contains(j.methods, alg) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidAlgorithm, alg)
 	}
-	return j.claims.mapClaims, nil
+	return j.keyFunc(t)
 }
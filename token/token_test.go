@@ -1,6 +1,12 @@
 package token_test
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
 	"fmt"
 	"net/http"
 	"testing"
@@ -83,3 +89,144 @@ func TestVerifyTokenWithBadInput(t *testing.T) {
 		require.Error(err)
 	}
 }
+
+func signedClaims(r *require.Assertions, claims gojwt.MapClaims) *http.Request {
+	private, err := gojwt.ParseRSAPrivateKeyFromPEM(priv)
+	r.NoError(err)
+	claims["permissions"] = 4
+	stoken, err := gojwt.NewWithClaims(gojwt.SigningMethodRS256, claims).SignedString(private)
+	r.NoError(err)
+	return &http.Request{
+		Header: http.Header{"Authorization": []string{"Bearer " + stoken}},
+	}
+}
+
+func TestVerifyRegisteredClaims(t *testing.T) {
+	require := require.New(t)
+	public, err := gojwt.ParseRSAPublicKeyFromPEM(pub)
+	require.NoError(err)
+
+	now := time.Now()
+	exp := now.Add(time.Hour).Unix()
+	tests := []struct {
+		name   string
+		opts   []token.Option
+		claims gojwt.MapClaims
+		err    error
+	}{
+		{"expired", nil, gojwt.MapClaims{"exp": now.Add(-time.Minute).Unix()}, token.ErrExpired},
+		{"expired in leeway", []token.Option{token.WithLeeway(2 * time.Minute)},
+			gojwt.MapClaims{"exp": now.Add(-time.Minute).Unix()}, nil},
+		{"not valid yet", nil, gojwt.MapClaims{"exp": exp, "nbf": now.Add(time.Minute).Unix()}, token.ErrNotValidYet},
+		{"not valid yet in leeway", []token.Option{token.WithLeeway(2 * time.Minute)},
+			gojwt.MapClaims{"exp": exp, "nbf": now.Add(time.Minute).Unix()}, nil},
+		{"issued in future", nil, gojwt.MapClaims{"exp": exp, "iat": now.Add(time.Minute).Unix()}, token.ErrIssuedInFuture},
+		{"max age without iat", []token.Option{token.WithMaxAge(time.Hour)},
+			gojwt.MapClaims{"exp": exp}, token.ErrNoIssuedAt},
+		{"too old", []token.Option{token.WithMaxAge(time.Hour)},
+			gojwt.MapClaims{"exp": exp, "iat": now.Add(-2 * time.Hour).Unix()}, token.ErrTooOld},
+		{"young enough", []token.Option{token.WithMaxAge(time.Hour)},
+			gojwt.MapClaims{"exp": exp, "iat": now.Add(-time.Minute).Unix()}, nil},
+		{"issuer", []token.Option{token.WithIssuer("auth", "sso")},
+			gojwt.MapClaims{"exp": exp, "iss": "sso"}, nil},
+		{"wrong issuer", []token.Option{token.WithIssuer("auth")},
+			gojwt.MapClaims{"exp": exp, "iss": "billing"}, token.ErrInvalidIssuer},
+		{"missing issuer", []token.Option{token.WithIssuer("auth")},
+			gojwt.MapClaims{"exp": exp}, token.ErrInvalidIssuer},
+		{"audience", []token.Option{token.WithAudience("api")},
+			gojwt.MapClaims{"exp": exp, "aud": "api"}, nil},
+		{"audience list", []token.Option{token.WithAudience("api")},
+			gojwt.MapClaims{"exp": exp, "aud": []string{"web", "api"}}, nil},
+		{"wrong audience", []token.Option{token.WithAudience("api")},
+			gojwt.MapClaims{"exp": exp, "aud": []string{"web"}}, token.ErrInvalidAudience},
+	}
+	for _, test := range tests {
+		jwt := token.NewJWT(public, test.opts...)
+		_, err := jwt.Verify(signedClaims(require, test.claims))
+		if test.err == nil {
+			require.NoError(err, test.name)
+			continue
+		}
+		require.True(errors.Is(err, test.err), "%s: %v", test.name, err)
+	}
+}
+
+func TestSigningAlgorithms(t *testing.T) {
+	require := require.New(t)
+	secret := bytes.Repeat([]byte("s"), 64)
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(err)
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(err)
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(err)
+	rsaPublic, err := gojwt.ParseRSAPublicKeyFromPEM(pub)
+	require.NoError(err)
+	rsaPrivate, err := gojwt.ParseRSAPrivateKeyFromPEM(priv)
+	require.NoError(err)
+
+	tests := []struct {
+		name   string
+		jwt    *token.JWT
+		method gojwt.SigningMethod
+		key    interface{}
+		err    error
+	}{
+		{"HS256", token.NewHMAC(secret), gojwt.SigningMethodHS256, secret, nil},
+		{"HS512", token.NewHMAC(secret), gojwt.SigningMethodHS512, secret, nil},
+		{"HS512 with a short secret", token.NewHMAC(secret[:32]),
+			gojwt.SigningMethodHS512, secret[:32], token.ErrInvalidAlgorithm},
+		{"ES256", token.NewECDSA(&p256.PublicKey), gojwt.SigningMethodES256, p256, nil},
+		{"ES384", token.NewECDSA(&p384.PublicKey), gojwt.SigningMethodES384, p384, nil},
+		{"EdDSA", token.NewEd25519(edPublic), token.SigningMethodEdDSA, edPrivate, nil},
+		{"PS256", token.NewJWT(rsaPublic), gojwt.SigningMethodPS256, rsaPrivate, nil},
+		{"pinned RS256", token.NewJWT(rsaPublic, token.WithAlgorithms("RS256")),
+			gojwt.SigningMethodRS512, rsaPrivate, token.ErrInvalidAlgorithm},
+		{"short secret pinned to HS256", token.NewHMAC(secret[:32], token.WithAlgorithms("HS256", "HS512")),
+			gojwt.SigningMethodHS512, secret[:32], token.ErrInvalidAlgorithm},
+		{"ES256 key with ES384", token.NewECDSA(&p256.PublicKey),
+			gojwt.SigningMethodES384, p384, token.ErrInvalidAlgorithm},
+		{"HMAC with RSA", token.NewJWT(rsaPublic), gojwt.SigningMethodHS256, pub, token.ErrInvalidAlgorithm},
+		{"RSA with HMAC", token.NewHMAC(secret), gojwt.SigningMethodRS256, rsaPrivate, token.ErrInvalidAlgorithm},
+		{"EdDSA with ECDSA", token.NewECDSA(&p256.PublicKey),
+			token.SigningMethodEdDSA, edPrivate, token.ErrInvalidAlgorithm},
+	}
+	for _, test := range tests {
+		_, err := test.jwt.Verify(signed(require, test.method, "", test.key))
+		if test.err == nil {
+			require.NoError(err, test.name)
+			continue
+		}
+		require.True(errors.Is(err, test.err), "%s: %v", test.name, err)
+	}
+
+	require.Panics(func() { token.NewHMAC(nil) })
+	require.Panics(func() { token.NewHMAC([]byte("secret")) })
+	// the algorithms can't widen the ones accepted by the constructor
+	require.Panics(func() { token.NewHMAC(secret[:32], token.WithAlgorithms("HS512")) })
+	require.Panics(func() { token.NewJWT(rsaPublic, token.WithAlgorithms("ES256")) })
+
+	other, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(err)
+	_, err = token.NewEd25519(other).Verify(signed(require, token.SigningMethodEdDSA, "", edPrivate))
+	require.True(errors.Is(err, token.ErrEdDSAVerification))
+}
+
+func TestWithoutPermissionClaim(t *testing.T) {
+	require := require.New(t)
+	public, err := gojwt.ParseRSAPublicKeyFromPEM(pub)
+	require.NoError(err)
+	private, err := gojwt.ParseRSAPrivateKeyFromPEM(priv)
+	require.NoError(err)
+	stoken, err := gojwt.NewWithClaims(gojwt.SigningMethodRS256, gojwt.MapClaims{
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": []string{"admin"},
+	}).SignedString(private)
+	require.NoError(err)
+
+	_, err = token.NewJWT(public).Verify(bearer(stoken))
+	require.Error(err)
+	claims, err := token.NewJWT(public, token.WithoutPermissionClaim()).Verify(bearer(stoken))
+	require.NoError(err)
+	require.Equal([]interface{}{"admin"}, claims["roles"])
+}
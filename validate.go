@@ -0,0 +1,234 @@
+package rester
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hoenirvili/rester/permission"
+	"github.com/hoenirvili/rester/query"
+	"github.com/hoenirvili/rester/resource"
+	"github.com/hoenirvili/rester/route"
+	"github.com/hoenirvili/rester/validate"
+)
+
+// ConfigError describes a single configuration problem
+// found while building the router
+type ConfigError struct {
+	// Resource is the base path of the resource, if any
+	Resource string
+	// Method is the http method of the route, if any
+	Method string
+	// URL is the relative URL of the route, if any
+	URL string
+	// Err is the underlying problem
+	Err error
+}
+
+// Error returns the problem prefixed by the resource and route it belongs to
+func (c *ConfigError) Error() string {
+	var where []string
+	if c.Resource != "" {
+		where = append(where, "resource "+c.Resource)
+	}
+	if c.Method != "" || c.URL != "" {
+		where = append(where, strings.TrimSpace(c.Method+" "+c.URL))
+	}
+	if len(where) == 0 {
+		return c.Err.Error()
+	}
+	return strings.Join(where, ", ") + ": " + c.Err.Error()
+}
+
+// Unwrap returns the underlying problem
+func (c *ConfigError) Unwrap() error { return c.Err }
+
+// BuildError aggregates all configuration problems found by Build
+type BuildError []*ConfigError
+
+// Error returns a report containing every configuration problem, one per line
+func (b BuildError) Error() string {
+	lines := make([]string, 0, len(b)+1)
+	lines = append(lines, fmt.Sprintf("rester: %d configuration error(s)", len(b)))
+	for _, err := range b {
+		lines = append(lines, "\t"+err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// methods holds all http methods that a route can respond to
+var methods = map[string]bool{
+	resource.Get:     true,
+	resource.Head:    true,
+	resource.Post:    true,
+	resource.Put:     true,
+	resource.Patch:   true,
+	resource.Delete:  true,
+	resource.Connect: true,
+	resource.Options: true,
+	resource.Trace:   true,
+}
+
+// validAllow checks if every bit set in p is a valid permission
+func validAllow(p permission.Permissions) bool {
+	for bit := permission.Permissions(1); bit != 0 && bit <= p; bit <<= 1 {
+		if p&bit != 0 && !bit.Valid() {
+			return false
+		}
+	}
+	return true
+}
+
+// validRoute returns all the problems found in the route
+func validRoute(route route.Route) []error {
+	var errs []error
+	if route.Handler == nil {
+		errs = append(errs, errors.New("cannot use a nil handler"))
+	}
+	if route.URL == "" {
+		errs = append(errs, errors.New("cannot use an empty URL route"))
+	} else if !strings.HasPrefix(route.URL, "/") {
+		errs = append(errs, errors.New("the URL route must begin with '/'"))
+	}
+	if !methods[route.Method] {
+		errs = append(errs, fmt.Errorf("unknown http method %q", route.Method))
+	}
+	if !validAllow(route.Allow) {
+		errs = append(errs, fmt.Errorf("invalid permission value %d", route.Allow))
+	}
+	for _, role := range route.Roles {
+		if _, ok := permission.Lookup(role); !ok {
+			errs = append(errs, fmt.Errorf("unknown role %q", role))
+		}
+	}
+	if route.Body != nil {
+		if reflect.TypeOf(route.Body).Kind() != reflect.Struct {
+			errs = append(errs, errors.New("the body type must be a struct"))
+		} else if err := validate.Check(route.Body); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for key, value := range route.QueryPairs {
+		if key == "" {
+			errs = append(errs, errors.New("cannot use an empty query key"))
+			continue
+		}
+		if value.List == query.Single && (value.MinItems != 0 || value.MaxItems != 0) {
+			errs = append(errs, fmt.Errorf("query key %q declares item counts but it's not a list", key))
+		}
+		if value.MinItems < 0 || value.MaxItems < 0 ||
+			(value.MaxItems > 0 && value.MaxItems < value.MinItems) {
+			errs = append(errs, fmt.Errorf("query key %q has invalid item counts", key))
+		}
+		if value.Default != "" {
+			if value.Required {
+				errs = append(errs, fmt.Errorf("required query key %q cannot have a default", key))
+			} else if err := route.QueryPairs.ParseDefault(key).Error(); err != nil {
+				errs = append(errs, fmt.Errorf("invalid default for query key %q: %s", key, err))
+			}
+		}
+		if urlKey := value.URLKey(key); urlKey != key {
+			if _, ok := route.QueryPairs[urlKey]; ok {
+				errs = append(errs, fmt.Errorf("query keys %q and %q collide", key, urlKey))
+			}
+		}
+	}
+	return errs
+}
+
+// validate checks all the configuration and resources returning
+// a BuildError containing every problem found
+func (r *Rester) validate() error {
+	errs := BuildError(r.config.errs)
+	if r.options.mapper == nil {
+		errs = append(errs, &ConfigError{
+			Err: errors.New("cannot use a nil permission mapper"),
+		})
+	}
+	if r.options.roles == nil {
+		errs = append(errs, &ConfigError{
+			Err: errors.New("cannot use a nil role mapper"),
+		})
+	}
+	if t := r.options.customClaims; t != nil && t.Kind() != reflect.Struct {
+		errs = append(errs, &ConfigError{
+			Err: errors.New("the custom claims type must be a struct"),
+		})
+	}
+
+	bases := make([]string, 0, len(r.config.resources))
+	for base := range r.config.resources {
+		bases = append(bases, base)
+	}
+	sort.Strings(bases)
+
+	seen := make(map[string]string)
+	for _, base := range bases {
+		res := r.config.resources[base]
+		if !strings.HasPrefix(base, "/") {
+			errs = append(errs, &ConfigError{
+				Resource: base,
+				Err:      errors.New("the resource base must begin with '/'"),
+			})
+		}
+		if res == nil {
+			errs = append(errs, &ConfigError{
+				Resource: base,
+				Err:      errors.New("cannot use a nil resource"),
+			})
+			continue
+		}
+		// the OpenAPI document route is mounted after all resources
+		// and would silently replace the resource routes
+		document := r.options.openapi != nil
+		if document && joinURL(base) == OpenAPIPath {
+			errs = append(errs, &ConfigError{
+				Resource: base,
+				Err:      errors.New("the resource base collides with the OpenAPI document route"),
+			})
+			document = false
+		}
+		for _, route := range res.Routes() {
+			for _, err := range validRoute(route) {
+				errs = append(errs, &ConfigError{
+					Resource: base,
+					Method:   route.Method,
+					URL:      route.URL,
+					Err:      err,
+				})
+			}
+			if document && route.Method == resource.Get && joinURL(base, route.URL) == OpenAPIPath {
+				errs = append(errs, &ConfigError{
+					Resource: base,
+					Method:   route.Method,
+					URL:      route.URL,
+					Err:      errors.New("the route collides with the OpenAPI document route"),
+				})
+			}
+			key := route.Method + " " + joinURL(base, route.URL)
+			if other, ok := seen[key]; ok {
+				errs = append(errs, &ConfigError{
+					Resource: base,
+					Method:   route.Method,
+					URL:      route.URL,
+					Err:      fmt.Errorf("route already defined by resource %s", other),
+				})
+				continue
+			}
+			seen[key] = base
+		}
+	}
+
+	if r.options.rbac != nil && len(errs) == 0 {
+		_, perrs := r.compilePolicy(r.options.rbac)
+		errs = append(errs, perrs...)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
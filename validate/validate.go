@@ -0,0 +1,394 @@
+// Package validate offers declarative struct validation
+// based on the rules found in the struct field tags
+//
+// The rules are declared using the "validate" tag separated by commas:
+//
+//	type user struct {
+//		Name  string `json:"name" validate:"required,min=3,max=20"`
+//		Email string `json:"email" validate:"required,email"`
+//		Role  string `json:"role" validate:"oneof=basic admin"`
+//		Code  string `json:"code" validate:"len=6,regexp=^[0-9]+$"`
+//	}
+//
+// Supported rules are required, omitempty, min, max, len, oneof, email and regexp.
+// The regexp rule consumes the rest of the tag so it must be the last one.
+// The rules are applied to zero values too, e.g. `validate:"min=18"` rejects 0.
+// Optional fields should be pointers, a nil pointer is not validated unless
+// it's required, or use omitempty to skip the rules when the field is zero:
+//
+//	Nickname string `json:"nickname" validate:"omitempty,min=3"`
+//
+// Use Check for finding the invalid rules of a type before validating any value.
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Tag is the struct tag name holding the validation rules
+const Tag = "validate"
+
+// FieldError describes a field that does not respect one of it's rules
+type FieldError struct {
+	// Field is the path of the field, using the json names if any
+	Field string `json:"field"`
+	// Rule is the name of the rule that failed
+	Rule string `json:"rule"`
+	// Message is a human-readable explanation of the failure
+	Message string `json:"message"`
+}
+
+// Error returns the field path and the failure message
+func (f FieldError) Error() string {
+	return f.Field + ": " + f.Message
+}
+
+// Errors holds all field errors found while validating a value
+type Errors []FieldError
+
+// Error returns all field errors separated by "; "
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Struct validates v and all the nested structs of v
+// If any field does not respect it's rules Errors is returned
+// If v has invalid rules a plain error is returned
+func Struct(v interface{}) error {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return errors.New("validate: cannot validate a nil value")
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return fmt.Errorf("validate: cannot validate a non struct value of type %s", value.Type())
+	}
+
+	var errs Errors
+	if err := walk(value, "", &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Check checks that all the rules of the type of v and of
+// it's nested struct types are valid, without validating v
+// It returns the first unknown rule or invalid rule parameter found
+func Check(v interface{}) error {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return errors.New("validate: cannot check a nil value")
+	}
+	return checkType(t, "", map[reflect.Type]bool{})
+}
+
+func checkType(t reflect.Type, path string, seen map[reflect.Type]bool) error {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return checkType(t.Elem(), path, seen)
+	case reflect.Struct:
+	default:
+		return nil
+	}
+	if seen[t] {
+		return nil
+	}
+	seen[t] = true
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		p := join(path, name(field))
+		if field.Anonymous {
+			p = path
+		}
+		if tag, ok := field.Tag.Lookup(Tag); ok {
+			ft := field.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			// the rules report their invalid parameters
+			// and unsupported types for any value
+			var errs Errors
+			if err := apply(reflect.Zero(ft), p, split(tag), &errs); err != nil {
+				return err
+			}
+		}
+		if err := checkType(field.Type, p, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// name returns the name of the field used in error paths
+func name(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func walk(value reflect.Value, path string, errs *Errors) error {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if value.IsNil() {
+			return nil
+		}
+		return walk(value.Elem(), path, errs)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			p := path + "[" + strconv.Itoa(i) + "]"
+			if err := walk(value.Index(i), p, errs); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+	default:
+		return nil
+	}
+
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		p := join(path, name(field))
+		if field.Anonymous {
+			p = path
+		}
+		fv := value.Field(i)
+		if tag, ok := field.Tag.Lookup(Tag); ok {
+			if err := check(fv, p, tag, errs); err != nil {
+				return err
+			}
+		}
+		if err := walk(fv, p, errs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func check(value reflect.Value, path, tag string, errs *Errors) error {
+	rules := split(tag)
+	zero := value.IsZero()
+	for _, rule := range rules {
+		if rule.name == "required" && zero {
+			*errs = append(*errs, FieldError{path, "required", "is required"})
+			return nil
+		}
+	}
+	for _, rule := range rules {
+		if rule.name == "omitempty" && zero {
+			return nil
+		}
+	}
+
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	return apply(value, path, rules, errs)
+}
+
+// apply validates the value using all the rules
+func apply(value reflect.Value, path string, rules []rule, errs *Errors) error {
+	for _, rule := range rules {
+		fn, ok := rulesFuncs[rule.name]
+		if !ok {
+			return fmt.Errorf("validate: unknown rule %q on field %s", rule.name, path)
+		}
+		msg, err := fn(value, rule.param)
+		if err != nil {
+			return fmt.Errorf("validate: invalid rule %q on field %s: %w", rule.name, path, err)
+		}
+		if msg != "" {
+			*errs = append(*errs, FieldError{path, rule.name, msg})
+		}
+	}
+	return nil
+}
+
+type rule struct {
+	name  string
+	param string
+}
+
+func split(tag string) []rule {
+	var rules []rule
+	for tag != "" {
+		var part string
+		if strings.HasPrefix(tag, "regexp=") {
+			part, tag = tag, ""
+		} else if n := strings.IndexByte(tag, ','); n >= 0 {
+			part, tag = tag[:n], tag[n+1:]
+		} else {
+			part, tag = tag, ""
+		}
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		r := rule{name: part}
+		if n := strings.IndexByte(part, '='); n >= 0 {
+			r.name, r.param = part[:n], part[n+1:]
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// ruleFunc returns a non empty message if the value does not respect the rule
+type ruleFunc func(value reflect.Value, param string) (string, error)
+
+var rulesFuncs map[string]ruleFunc
+
+func init() {
+	rulesFuncs = map[string]ruleFunc{
+		"required":  func(reflect.Value, string) (string, error) { return "", nil },
+		"omitempty": func(reflect.Value, string) (string, error) { return "", nil },
+		"min":       bound("min", func(n, p float64) bool { return n >= p }),
+		"max":       bound("max", func(n, p float64) bool { return n <= p }),
+		"len":       length,
+		"oneof":     oneof,
+		"email":     email,
+		"regexp":    match,
+	}
+}
+
+// size returns the number used when comparing the value against
+// a bound, the length for strings, slices and maps
+func size(value reflect.Value) (float64, bool, error) {
+	switch value.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return float64(value.Len()), true, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), false, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), false, nil
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), false, nil
+	default:
+		return 0, false, fmt.Errorf("unsupported type %s", value.Type())
+	}
+}
+
+func bound(name string, ok func(n, p float64) bool) ruleFunc {
+	return func(value reflect.Value, param string) (string, error) {
+		p, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return "", err
+		}
+		n, isLen, err := size(value)
+		if err != nil {
+			return "", err
+		}
+		if ok(n, p) {
+			return "", nil
+		}
+		if isLen {
+			return fmt.Sprintf("length must be at %s %s", limit(name), param), nil
+		}
+		return fmt.Sprintf("must be at %s %s", limit(name), param), nil
+	}
+}
+
+func limit(name string) string {
+	if name == "min" {
+		return "least"
+	}
+	return "most"
+}
+
+func length(value reflect.Value, param string) (string, error) {
+	p, err := strconv.Atoi(param)
+	if err != nil {
+		return "", err
+	}
+	switch value.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+	default:
+		return "", fmt.Errorf("unsupported type %s", value.Type())
+	}
+	if value.Len() != p {
+		return "length must be exactly " + param, nil
+	}
+	return "", nil
+}
+
+func oneof(value reflect.Value, param string) (string, error) {
+	allowed := strings.Fields(param)
+	str := fmt.Sprint(value.Interface())
+	for _, a := range allowed {
+		if a == str {
+			return "", nil
+		}
+	}
+	return "must be one of " + strings.Join(allowed, ", "), nil
+}
+
+func email(value reflect.Value, _ string) (string, error) {
+	if value.Kind() != reflect.String {
+		return "", fmt.Errorf("unsupported type %s", value.Type())
+	}
+	str := value.String()
+	addr, err := mail.ParseAddress(str)
+	if err != nil || addr.Address != str {
+		return "must be a valid email address", nil
+	}
+	return "", nil
+}
+
+var expressions sync.Map
+
+func match(value reflect.Value, param string) (string, error) {
+	if value.Kind() != reflect.String {
+		return "", fmt.Errorf("unsupported type %s", value.Type())
+	}
+	var re *regexp.Regexp
+	if v, ok := expressions.Load(param); ok {
+		re = v.(*regexp.Regexp)
+	} else {
+		compiled, err := regexp.Compile(param)
+		if err != nil {
+			return "", err
+		}
+		expressions.Store(param, compiled)
+		re = compiled
+	}
+	if !re.MatchString(value.String()) {
+		return "must match " + param, nil
+	}
+	return "", nil
+}
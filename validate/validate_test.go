@@ -0,0 +1,144 @@
+package validate_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoenirvili/rester/validate"
+)
+
+type address struct {
+	City string `json:"city" validate:"required"`
+}
+
+type user struct {
+	Name     string    `json:"name" validate:"required,min=3,max=5"`
+	Email    string    `json:"email" validate:"email"`
+	Role     string    `json:"role" validate:"oneof=basic admin"`
+	Code     string    `json:"code" validate:"len=4,regexp=^[0-9,]+$"`
+	Age      int       `json:"age" validate:"min=18"`
+	Tags     []string  `json:"tags" validate:"max=2"`
+	Address  *address  `json:"address" validate:"required"`
+	Previous []address `json:"previous"`
+}
+
+func TestStruct(t *testing.T) {
+	require := require.New(t)
+	err := validate.Struct(&user{
+		Name:     "test",
+		Email:    "test@example.com",
+		Role:     "admin",
+		Code:     "1,23",
+		Age:      20,
+		Address:  &address{"Iasi"},
+		Previous: []address{{"Cluj"}},
+	})
+	require.NoError(err)
+}
+
+func TestStructWithErrors(t *testing.T) {
+	require := require.New(t)
+	err := validate.Struct(user{
+		Name:     "te",
+		Email:    "test",
+		Role:     "super",
+		Code:     "12a4",
+		Age:      10,
+		Tags:     []string{"a", "b", "c"},
+		Previous: []address{{"Cluj"}, {}},
+	})
+	require.Error(err)
+	errs, ok := err.(validate.Errors)
+	require.True(ok)
+	require.Equal(validate.Errors{
+		{Field: "name", Rule: "min", Message: "length must be at least 3"},
+		{Field: "email", Rule: "email", Message: "must be a valid email address"},
+		{Field: "role", Rule: "oneof", Message: "must be one of basic, admin"},
+		{Field: "code", Rule: "regexp", Message: "must match ^[0-9,]+$"},
+		{Field: "age", Rule: "min", Message: "must be at least 18"},
+		{Field: "tags", Rule: "max", Message: "length must be at most 2"},
+		{Field: "address", Rule: "required", Message: "is required"},
+		{Field: "previous[1].city", Rule: "required", Message: "is required"},
+	}, errs)
+}
+
+func TestStructZeroValues(t *testing.T) {
+	require := require.New(t)
+	type order struct {
+		Qty      int    `json:"qty" validate:"min=1,max=10"`
+		Age      int    `json:"age" validate:"min=18"`
+		Nickname string `json:"nickname" validate:"omitempty,min=3"`
+		Discount *int   `json:"discount" validate:"max=50"`
+	}
+	err := validate.Struct(order{})
+	require.Equal(validate.Errors{
+		{Field: "qty", Rule: "min", Message: "must be at least 1"},
+		{Field: "age", Rule: "min", Message: "must be at least 18"},
+	}, err)
+
+	zero := 0
+	require.NoError(validate.Struct(order{Qty: 1, Age: 18, Discount: &zero}))
+	err = validate.Struct(order{Qty: 1, Age: 18, Nickname: "ab"})
+	require.Equal(validate.Errors{
+		{Field: "nickname", Rule: "min", Message: "length must be at least 3"},
+	}, err)
+}
+
+func TestStructInvalidRule(t *testing.T) {
+	require := require.New(t)
+	err := validate.Struct(&struct {
+		Name string `validate:"unknown"`
+	}{"test"})
+	require.Error(err)
+	_, ok := err.(validate.Errors)
+	require.False(ok)
+
+	err = validate.Struct(&struct {
+		Name string `validate:"min=a"`
+	}{"test"})
+	require.Error(err)
+	_, ok = err.(validate.Errors)
+	require.False(ok)
+}
+
+func TestStructNonStruct(t *testing.T) {
+	require := require.New(t)
+	require.Error(validate.Struct(10))
+	require.Error(validate.Struct((*user)(nil)))
+}
+
+type invalidAddress struct {
+	Zip string `json:"zip" validate:"regexp=[0-9"`
+}
+
+func TestCheck(t *testing.T) {
+	require := require.New(t)
+	require.NoError(validate.Check(user{}))
+	require.NoError(validate.Check(&user{}))
+
+	for _, v := range []interface{}{
+		struct {
+			Name string `validate:"unknown"`
+		}{},
+		struct {
+			Age *int `validate:"min=a"`
+		}{},
+		struct {
+			Name string `validate:"len=6,max=b"`
+		}{},
+		struct {
+			Age int `validate:"email"`
+		}{},
+		struct {
+			Previous []invalidAddress `json:"previous"`
+		}{},
+	} {
+		require.Error(validate.Check(v), "%T", v)
+	}
+	err := validate.Check(struct {
+		Previous []invalidAddress `json:"previous"`
+	}{})
+	require.Contains(err.Error(), "previous.zip")
+	require.Error(validate.Check(nil))
+}
@@ -0,0 +1,64 @@
+package value
+
+import (
+	"net"
+	"time"
+)
+
+// Get returns the value as a T
+// If the value could not be parsed or it can't be stored into
+// a T, the zero value of T and an error are returned
+func Get[T any](v Value) (T, error) {
+	var t T
+	if err := v.As(&t); err != nil {
+		var zero T
+		return zero, err
+	}
+	return t, nil
+}
+
+// Or returns the value as a T or d if the value could not be
+// parsed or it can't be stored into a T
+func Or[T any](v Value, d T) T {
+	t, err := Get[T](v)
+	if err != nil {
+		return d
+	}
+	return t
+}
+
+func ok[T any](v Value) (T, bool) {
+	t, err := Get[T](v)
+	return t, err == nil
+}
+
+// The accessors below never panic, the Ok variants report if the value
+// was parsed and is of the requested type and the Or variants return
+// the given default instead
+
+func (v Value) StringOk() (string, bool)                 { return ok[string](v) }
+func (v Value) IntOk() (int, bool)                       { return ok[int](v) }
+func (v Value) Int64Ok() (int64, bool)                   { return ok[int64](v) }
+func (v Value) Uint64Ok() (uint64, bool)                 { return ok[uint64](v) }
+func (v Value) DateOk() (time.Time, bool)                { return ok[time.Time](v) }
+func (v Value) BoolOk() (bool, bool)                     { return ok[bool](v) }
+func (v Value) Float64Ok() (float64, bool)               { return ok[float64](v) }
+func (v Value) UUIDOk() (string, bool)                   { return ok[string](v) }
+func (v Value) TimeOk() (time.Time, bool)                { return ok[time.Time](v) }
+func (v Value) DurationOk() (time.Duration, bool)        { return ok[time.Duration](v) }
+func (v Value) IPOk() (net.IP, bool)                     { return ok[net.IP](v) }
+func (v Value) CIDROk() (*net.IPNet, bool)               { return ok[*net.IPNet](v) }
+func (v Value) EnumOk() (string, bool)                   { return ok[string](v) }
+func (v Value) StringOr(d string) string                 { return Or(v, d) }
+func (v Value) IntOr(d int) int                          { return Or(v, d) }
+func (v Value) Int64Or(d int64) int64                    { return Or(v, d) }
+func (v Value) Uint64Or(d uint64) uint64                 { return Or(v, d) }
+func (v Value) DateOr(d time.Time) time.Time             { return Or(v, d) }
+func (v Value) BoolOr(d bool) bool                       { return Or(v, d) }
+func (v Value) Float64Or(d float64) float64              { return Or(v, d) }
+func (v Value) UUIDOr(d string) string                   { return Or(v, d) }
+func (v Value) TimeOr(d time.Time) time.Time             { return Or(v, d) }
+func (v Value) DurationOr(d time.Duration) time.Duration { return Or(v, d) }
+func (v Value) IPOr(d net.IP) net.IP                     { return Or(v, d) }
+func (v Value) CIDROr(d *net.IPNet) *net.IPNet           { return Or(v, d) }
+func (v Value) EnumOr(d string) string                   { return Or(v, d) }
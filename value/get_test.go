@@ -0,0 +1,61 @@
+package value_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoenirvili/rester/value"
+)
+
+func TestGet(t *testing.T) {
+	require := require.New(t)
+	n, err := value.Get[int](value.Parse("10", value.Int))
+	require.NoError(err)
+	require.Equal(10, n)
+
+	ids, err := value.Get[[]int64](value.ParseList([]string{"1", "2"}, value.Int64))
+	require.NoError(err)
+	require.Equal([]int64{1, 2}, ids)
+
+	_, err = value.Get[string](value.Parse("10", value.Int))
+	require.Error(err)
+
+	_, err = value.Get[int](value.Parse("a", value.Int))
+	require.Error(err)
+
+	_, err = value.Get[int](value.Parse("", value.Int))
+	require.Equal(value.ErrNoValueFound, err)
+}
+
+func TestOr(t *testing.T) {
+	require := require.New(t)
+	require.Equal(10, value.Or(value.Parse("10", value.Int), 5))
+	require.Equal(5, value.Or(value.Parse("a", value.Int), 5))
+	require.Equal(5, value.Or(value.Parse("10", value.Int64), 5))
+}
+
+func TestAccessorsDoNotPanic(t *testing.T) {
+	require := require.New(t)
+	v := value.Parse("", value.Int)
+	require.NotPanics(func() {
+		_, ok := v.IntOk()
+		require.False(ok)
+		_, ok = v.DateOk()
+		require.False(ok)
+		_, ok = v.CIDROk()
+		require.False(ok)
+		require.Equal(3, v.IntOr(3))
+		require.Equal(uint64(3), v.Uint64Or(3))
+		require.Equal(time.Second, v.DurationOr(time.Second))
+	})
+
+	v = value.Parse("true", value.Bool)
+	b, ok := v.BoolOk()
+	require.True(ok)
+	require.True(b)
+	_, ok = v.StringOk()
+	require.False(ok)
+	require.Equal("default", v.StringOr("default"))
+}
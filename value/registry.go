@@ -0,0 +1,169 @@
+package value
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Parser parses the raw input into a value
+type Parser func(input string) (interface{}, error)
+
+// Schema holds an OpenAPI schema hint describing the values of a Type
+type Schema struct {
+	Type    string
+	Format  string
+	Pattern string
+	Enum    []string
+}
+
+type definition struct {
+	name   string
+	parse  Parser
+	schema Schema
+}
+
+// custom is the first Type used for types created at runtime
+const custom Type = 1 << 8
+
+var registry = struct {
+	sync.RWMutex
+	next  Type
+	types map[Type]definition
+	names map[string]Type
+	// enums holds the enum types keyed by their allowed values
+	enums map[string]Type
+}{
+	next:  custom,
+	types: make(map[Type]definition),
+	names: make(map[string]Type),
+	enums: make(map[string]Type),
+}
+
+func builtin(t Type, name string, fn Parser, schema Schema) {
+	registry.types[t] = definition{name, fn, schema}
+	registry.names[name] = t
+}
+
+func lookup(t Type) (definition, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	d, ok := registry.types[t]
+	return d, ok
+}
+
+func register(d definition) Type {
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.names[d.name]; ok && d.name != "" {
+		panic("value: Register called twice for type " + d.name)
+	}
+	return add(d)
+}
+
+// add adds the definition, the registry must be locked
+// It panics if all the custom types are used
+func add(d definition) Type {
+	t := registry.next
+	if t < custom {
+		panic("value: too many types registered")
+	}
+	registry.next++
+	registry.types[t] = d
+	if d.name != "" {
+		registry.names[d.name] = t
+	}
+	return t
+}
+
+// Register registers a new Type that uses fn to parse the inputs
+// The optional schema hint is used to describe the type in OpenAPI
+// documents, if it's not given the type is described as a string
+// If the name was already registered or fn is nil, Register panics
+func Register(name string, fn Parser, hint ...Schema) Type {
+	if name == "" {
+		panic("value: Register called with an empty name")
+	}
+	if fn == nil {
+		panic("value: Register called with a nil parser for type " + name)
+	}
+	schema := Schema{Type: "string"}
+	if len(hint) > 0 {
+		schema = hint[0]
+	}
+	parse := func(input string) (interface{}, error) {
+		raw, err := fn(input)
+		if err != nil {
+			return nil, fmt.Errorf(`cannot parse the given input "%s" into %s: %w`, input, name, err)
+		}
+		return raw, nil
+	}
+	return register(definition{name, parse, schema})
+}
+
+// Lookup returns the Type registered with the given name
+func Lookup(name string) (Type, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	t, ok := registry.names[name]
+	return t, ok
+}
+
+// Enum returns a string Type that accepts only the allowed values
+// Calling Enum again with the same allowed values returns the same Type
+func Enum(allowed ...string) Type {
+	allowed = append([]string(nil), allowed...)
+	key := fmt.Sprintf("%q", allowed)
+
+	registry.Lock()
+	defer registry.Unlock()
+	if t, ok := registry.enums[key]; ok {
+		return t
+	}
+	parse := func(input string) (interface{}, error) {
+		for _, a := range allowed {
+			if a == input {
+				return input, nil
+			}
+		}
+		return nil, errors.New(`the given input "` + input +
+			`" is not one of ` + strings.Join(allowed, ", "))
+	}
+	t := add(definition{
+		parse:  parse,
+		schema: Schema{Type: "string", Enum: allowed},
+	})
+	registry.enums[key] = t
+	return t
+}
+
+// String returns the name of the type
+func (t Type) String() string {
+	d, ok := lookup(t)
+	switch {
+	case !ok:
+		return fmt.Sprintf("Type(%d)", uint16(t))
+	case d.name == "":
+		return "enum"
+	default:
+		return d.name
+	}
+}
+
+// Schema returns the OpenAPI schema hint of the type
+func (t Type) Schema() Schema {
+	d, ok := lookup(t)
+	if !ok {
+		return Schema{Type: "string"}
+	}
+	schema := d.schema
+	schema.Enum = append([]string(nil), schema.Enum...)
+	return schema
+}
+
+// Allowed returns all the values accepted by the Enum type t
+// If t is not an Enum type this returns nil
+func (t Type) Allowed() []string {
+	return t.Schema().Enum
+}
@@ -0,0 +1,73 @@
+package value_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoenirvili/rester/value"
+)
+
+type sku string
+
+var skuType = value.Register("sku", func(input string) (interface{}, error) {
+	if !strings.HasPrefix(input, "SKU-") {
+		return nil, errors.New("missing SKU- prefix")
+	}
+	return sku(input), nil
+}, value.Schema{Type: "string", Pattern: "^SKU-"})
+
+func TestRegister(t *testing.T) {
+	require := require.New(t)
+	require.Equal("sku", skuType.String())
+	require.Equal(value.Schema{Type: "string", Pattern: "^SKU-"}, skuType.Schema())
+
+	got, ok := value.Lookup("sku")
+	require.True(ok)
+	require.Equal(skuType, got)
+
+	v := value.Parse("SKU-1", skuType)
+	require.NoError(v.Error())
+	var s sku
+	require.NoError(v.As(&s))
+	require.Equal(sku("SKU-1"), s)
+
+	v = value.Parse("1", skuType)
+	require.EqualError(v.Error(), `cannot parse the given input "1" into sku: missing SKU- prefix`)
+	require.Error(v.As(&s))
+}
+
+func TestRegisterPanics(t *testing.T) {
+	require := require.New(t)
+	parser := func(input string) (interface{}, error) { return input, nil }
+	require.Panics(func() { value.Register("sku", parser) })
+	require.Panics(func() { value.Register("int", parser) })
+	require.Panics(func() { value.Register("", parser) })
+	require.Panics(func() { value.Register("nilparser", nil) })
+}
+
+func TestTypeString(t *testing.T) {
+	require := require.New(t)
+	require.Equal("int", value.Int.String())
+	require.Equal("enum", value.Enum("a").String())
+	require.Equal("Type(255)", value.Type(0xff).String())
+	require.Equal(value.Schema{Type: "integer", Format: "int32"}, value.Int.Schema())
+}
+
+func TestAs(t *testing.T) {
+	require := require.New(t)
+	var n int64
+	require.NoError(value.Parse("10", value.Int64).As(&n))
+	require.Equal(int64(10), n)
+
+	var ids []uint64
+	require.NoError(value.ParseList([]string{"1", "2"}, value.Uint64).As(&ids))
+	require.Equal([]uint64{1, 2}, ids)
+
+	var str string
+	require.Error(value.Parse("10", value.Int).As(&str))
+	require.Error(value.Parse("10", value.Int).As(str))
+	require.Error(value.Value{}.As(&str))
+}
@@ -4,18 +4,29 @@ package value
 
 import (
 	"errors"
+	"fmt"
+	"net"
+	"reflect"
 	"strconv"
+	"strings"
 	"time"
 )
 
-type Type uint8
+type Type uint16
 
 const (
 	String Type = iota
 	Int
 	Int64
 	Uint64
-	Date // for parsing simple date of the form 2006-01-02
+	Date     // for parsing simple date of the form 2006-01-02
+	Bool     // for parsing booleans like true, false, 1, 0
+	Float64  // for parsing floating point numbers
+	UUID     // for parsing uuids of the form 123e4567-e89b-12d3-a456-426614174000
+	Time     // for parsing RFC3339 timestamps like 2006-01-02T15:04:05Z
+	Duration // for parsing durations like 1h30m
+	IP       // for parsing IPv4 or IPv6 addresses
+	CIDR     // for parsing IP networks like 192.168.0.0/16
 )
 
 const formatTime = "2006-01-02"
@@ -23,41 +34,140 @@ const formatTime = "2006-01-02"
 // ErrNErrNoValueFound returned when the Parse methods is being called with an empty input
 var ErrNoValueFound = errors.New("No value found")
 
-func Parse(input string, t Type) Value {
-	if input == "" {
-		return Value{err: ErrNoValueFound}
-	}
+func failed(input, into string) error {
+	return errors.New(`cannot parse the given input "` + input + `" into ` + into)
+}
 
-	switch t {
-	case String:
-		return Value{input, nil}
-	case Int:
+func init() {
+	builtin(String, "string", func(input string) (interface{}, error) {
+		return input, nil
+	}, Schema{Type: "string"})
+	builtin(Int, "int", func(input string) (interface{}, error) {
 		n, err := strconv.ParseInt(input, 10, 32)
 		if err != nil {
-			err = errors.New(`cannot parse the given input "` + input + `" into Int`)
+			err = failed(input, "Int")
 		}
-		return Value{int(n), err}
-	case Int64:
+		return int(n), err
+	}, Schema{Type: "integer", Format: "int32"})
+	builtin(Int64, "int64", func(input string) (interface{}, error) {
 		n, err := strconv.ParseInt(input, 10, 64)
 		if err != nil {
-			err = errors.New(`cannot parse the given input "` + input + `" into Int64`)
+			err = failed(input, "Int64")
 		}
-		return Value{n, err}
-	case Uint64:
+		return n, err
+	}, Schema{Type: "integer", Format: "int64"})
+	builtin(Uint64, "uint64", func(input string) (interface{}, error) {
 		n, err := strconv.ParseUint(input, 10, 64)
 		if err != nil {
-			err = errors.New(`cannot parse the given input "` + input + `" into Uint64`)
+			err = failed(input, "Uint64")
 		}
-		return Value{n, err}
-	case Date:
+		return n, err
+	}, Schema{Type: "integer", Format: "int64"})
+	builtin(Date, "date", func(input string) (interface{}, error) {
 		t, err := time.Parse(formatTime, input)
 		if err != nil {
-			err = errors.New(`cannot parse the given input "` + input + `" into a time.Time`)
+			err = failed(input, "a time.Time")
 		}
-		return Value{t, err}
-	default:
+		return t, err
+	}, Schema{Type: "string", Format: "date"})
+	builtin(Bool, "bool", func(input string) (interface{}, error) {
+		b, err := strconv.ParseBool(input)
+		if err != nil {
+			err = failed(input, "Bool")
+		}
+		return b, err
+	}, Schema{Type: "boolean"})
+	builtin(Float64, "float64", func(input string) (interface{}, error) {
+		n, err := strconv.ParseFloat(input, 64)
+		if err != nil {
+			err = failed(input, "Float64")
+		}
+		return n, err
+	}, Schema{Type: "number", Format: "double"})
+	builtin(UUID, "uuid", func(input string) (interface{}, error) {
+		if !validUUID(input) {
+			return nil, failed(input, "UUID")
+		}
+		return strings.ToLower(input), nil
+	}, Schema{Type: "string", Format: "uuid"})
+	builtin(Time, "time", func(input string) (interface{}, error) {
+		t, err := time.Parse(time.RFC3339, input)
+		if err != nil {
+			err = failed(input, "a RFC3339 time.Time")
+		}
+		return t, err
+	}, Schema{Type: "string", Format: "date-time"})
+	builtin(Duration, "duration", func(input string) (interface{}, error) {
+		d, err := time.ParseDuration(input)
+		if err != nil {
+			err = failed(input, "a time.Duration")
+		}
+		return d, err
+	}, Schema{Type: "string", Format: "duration"})
+	builtin(IP, "ip", func(input string) (interface{}, error) {
+		ip := net.ParseIP(input)
+		if ip == nil {
+			return nil, failed(input, "IP")
+		}
+		return ip, nil
+	}, Schema{Type: "string", Format: "ip"})
+	builtin(CIDR, "cidr", func(input string) (interface{}, error) {
+		_, network, err := net.ParseCIDR(input)
+		if err != nil {
+			return nil, failed(input, "CIDR")
+		}
+		return network, nil
+	}, Schema{Type: "string", Format: "cidr"})
+}
+
+// validUUID checks if the input is an uuid of the canonical form
+func validUUID(input string) bool {
+	if len(input) != 36 {
+		return false
+	}
+	for i, c := range input {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func Parse(input string, t Type) Value {
+	if input == "" {
+		return Value{err: ErrNoValueFound}
+	}
+
+	d, ok := lookup(t)
+	if !ok {
 		return Value{nil, errors.New("unsupported type")}
 	}
+	raw, err := d.parse(input)
+	return Value{raw, err}
+}
+
+// ParseList parses every input into a single list value
+// All elements of the list are of type t
+func ParseList(inputs []string, t Type) Value {
+	if len(inputs) == 0 {
+		return Value{err: ErrNoValueFound}
+	}
+	list := make([]Value, 0, len(inputs))
+	for _, input := range inputs {
+		value := Parse(input, t)
+		if err := value.Error(); err != nil {
+			return Value{err: err}
+		}
+		list = append(list, value)
+	}
+	return Value{list, nil}
 }
 
 type Value struct {
@@ -74,7 +184,108 @@ func (v Value) String() string {
 	}
 	return str
 }
-func (v Value) Int64() int64    { return v.raw.(int64) }
-func (v Value) Int() int        { return v.raw.(int) }
-func (v Value) Uint64() uint64  { return v.raw.(uint64) }
-func (v Value) Date() time.Time { return v.raw.(time.Time) }
+func (v Value) Int64() int64            { return v.raw.(int64) }
+func (v Value) Int() int                { return v.raw.(int) }
+func (v Value) Uint64() uint64          { return v.raw.(uint64) }
+func (v Value) Date() time.Time         { return v.raw.(time.Time) }
+func (v Value) Bool() bool              { return v.raw.(bool) }
+func (v Value) Float64() float64        { return v.raw.(float64) }
+func (v Value) UUID() string            { return v.raw.(string) }
+func (v Value) Time() time.Time         { return v.raw.(time.Time) }
+func (v Value) Duration() time.Duration { return v.raw.(time.Duration) }
+func (v Value) IP() net.IP              { return v.raw.(net.IP) }
+func (v Value) CIDR() *net.IPNet        { return v.raw.(*net.IPNet) }
+func (v Value) Enum() string            { return v.raw.(string) }
+
+// As stores the value into the variable pointed by dst
+// dst must be a pointer to a type that the value can be assigned to
+// or to a named type of the same kind, like *SKU for a string value
+// List values can be stored into pointers to slices
+func (v Value) As(dst interface{}) error {
+	if v.err != nil {
+		return v.err
+	}
+	ptr := reflect.ValueOf(dst)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() {
+		return errors.New("cannot store a value into a non pointer or nil destination")
+	}
+	return v.as(ptr.Elem())
+}
+
+func (v Value) as(dst reflect.Value) error {
+	if list, ok := v.raw.([]Value); ok && dst.Kind() == reflect.Slice {
+		slice := reflect.MakeSlice(dst.Type(), len(list), len(list))
+		for i, elem := range list {
+			if err := elem.as(slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		dst.Set(slice)
+		return nil
+	}
+
+	raw := reflect.ValueOf(v.raw)
+	if !raw.IsValid() {
+		return ErrNoValueFound
+	}
+	switch {
+	case raw.Type().AssignableTo(dst.Type()):
+		dst.Set(raw)
+	case raw.Kind() == dst.Kind() && raw.Type().ConvertibleTo(dst.Type()):
+		dst.Set(raw.Convert(dst.Type()))
+	default:
+		return fmt.Errorf("cannot store a value of type %s into %s", raw.Type(), dst.Type())
+	}
+	return nil
+}
+
+// List returns all the elements of a list value
+func (v Value) List() []Value {
+	list, _ := v.raw.([]Value)
+	return list
+}
+
+func (v Value) Strings() []string {
+	list := v.List()
+	s := make([]string, 0, len(list))
+	for _, value := range list {
+		s = append(s, value.String())
+	}
+	return s
+}
+
+func (v Value) Int64s() []int64 {
+	list := v.List()
+	s := make([]int64, 0, len(list))
+	for _, value := range list {
+		s = append(s, value.Int64())
+	}
+	return s
+}
+
+func (v Value) Ints() []int {
+	list := v.List()
+	s := make([]int, 0, len(list))
+	for _, value := range list {
+		s = append(s, value.Int())
+	}
+	return s
+}
+
+func (v Value) Uint64s() []uint64 {
+	list := v.List()
+	s := make([]uint64, 0, len(list))
+	for _, value := range list {
+		s = append(s, value.Uint64())
+	}
+	return s
+}
+
+func (v Value) Dates() []time.Time {
+	list := v.List()
+	s := make([]time.Time, 0, len(list))
+	for _, value := range list {
+		s = append(s, value.Date())
+	}
+	return s
+}
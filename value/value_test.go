@@ -0,0 +1,98 @@
+package value_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoenirvili/rester/value"
+)
+
+func TestParse(t *testing.T) {
+	require := require.New(t)
+
+	v := value.Parse("true", value.Bool)
+	require.NoError(v.Error())
+	require.True(v.Bool())
+
+	v = value.Parse("3.14", value.Float64)
+	require.NoError(v.Error())
+	require.Equal(3.14, v.Float64())
+
+	v = value.Parse("123E4567-E89B-12D3-A456-426614174000", value.UUID)
+	require.NoError(v.Error())
+	require.Equal("123e4567-e89b-12d3-a456-426614174000", v.UUID())
+
+	v = value.Parse("2026-01-01T00:00:00Z", value.Time)
+	require.NoError(v.Error())
+	require.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), v.Time())
+
+	v = value.Parse("1h30m", value.Duration)
+	require.NoError(v.Error())
+	require.Equal(90*time.Minute, v.Duration())
+
+	v = value.Parse("::1", value.IP)
+	require.NoError(v.Error())
+	require.Equal(net.IPv6loopback, v.IP())
+
+	v = value.Parse("192.168.1.1/16", value.CIDR)
+	require.NoError(v.Error())
+	require.Equal("192.168.0.0/16", v.CIDR().String())
+}
+
+func TestParseWithError(t *testing.T) {
+	require := require.New(t)
+	inputs := map[value.Type]string{
+		value.Int:        "a",
+		value.Int64:      "1.2",
+		value.Uint64:     "-1",
+		value.Date:       "2026-01-01T00:00:00Z",
+		value.Bool:       "yes",
+		value.Float64:    "pi",
+		value.UUID:       "123e4567-e89b-12d3-a456-42661417400g",
+		value.Time:       "2026-01-01",
+		value.Duration:   "1 hour",
+		value.IP:         "256.0.0.1",
+		value.CIDR:       "192.168.0.0",
+		value.Type(0xff): "test",
+	}
+	for t, input := range inputs {
+		v := value.Parse(input, t)
+		require.Error(v.Error())
+	}
+	require.Equal(value.ErrNoValueFound, value.Parse("", value.String).Error())
+}
+
+func TestEnum(t *testing.T) {
+	require := require.New(t)
+	state := value.Enum("open", "closed")
+	require.Equal(state, value.Enum("open", "closed"))
+	require.NotEqual(state, value.Enum("closed", "open"))
+	require.NotEqual(state, value.Enum("open"))
+	require.Equal([]string{"open", "closed"}, state.Allowed())
+	require.Empty(value.String.Allowed())
+
+	v := value.Parse("open", state)
+	require.NoError(v.Error())
+	require.Equal("open", v.Enum())
+
+	v = value.Parse("merged", state)
+	require.EqualError(v.Error(), `the given input "merged" is not one of open, closed`)
+}
+
+func TestParseList(t *testing.T) {
+	require := require.New(t)
+	v := value.ParseList([]string{"1", "2"}, value.Uint64)
+	require.NoError(v.Error())
+	require.Equal([]uint64{1, 2}, v.Uint64s())
+	require.Len(v.List(), 2)
+
+	v = value.ParseList([]string{"1", "a"}, value.Int)
+	require.Error(v.Error())
+	require.Empty(v.List())
+
+	v = value.ParseList(nil, value.Int)
+	require.Equal(value.ErrNoValueFound, v.Error())
+}